@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+var (
+	ErrUnsupportedPerceptor = errors.New("perceptor kind is not supported")
+	ErrNoContent            = errors.New("item doesn't provide file contents")
+	ErrNoMetadata           = errors.New("item doesn't keep metadata")
+)
+
+// AsExifPerceptor lets raw, metadata and group perceptors run among ExifPerceptors, e.g. by BuildChain.
+// Items are passed to them and taken back as RawItemR, so the same item leaves the adapter:
+//   - raw perceptors get items which provide file contents, see FilePool.Wrap
+//   - metadata perceptors get a view of the item with metadata storage of the item it wraps
+//   - group perceptors emit items of every group one by one, once all items are grouped
+//
+// Perceptor should be configured before, adapter is not Configurable
+func AsExifPerceptor(p Perceptor) (ExifPerceptor, error) {
+	if p.ProcessingMode() == ItemGroup {
+		if g, ok := p.(GroupPerceptor); ok {
+			return &groupAdapter{adapter{p}, g}, nil
+		}
+		return nil, fmt.Errorf("%w: %s is %T in group mode", ErrUnsupportedPerceptor, p.Name(), p)
+	}
+
+	switch t := p.(type) {
+	case ExifPerceptor:
+		return t, nil
+	case RawPerceptor:
+		return &rawAdapter{adapter{p}, t}, nil
+	case MetadataPerceptor:
+		return &metadataAdapter{adapter{p}, t}, nil
+	}
+	return nil, fmt.Errorf("%w: %s is %T", ErrUnsupportedPerceptor, p.Name(), p)
+}
+
+// adapter forwards description of the adapted perceptor
+type adapter struct {
+	p Perceptor
+}
+
+func (a adapter) Name() string                   { return a.p.Name() }
+func (a adapter) DataProvider() DataProviderType { return a.p.DataProvider() }
+func (a adapter) ProcessingMode() ProcessingMode { return a.p.ProcessingMode() }
+func (a adapter) Version() string                { return PerceptorVersion(a.p) }
+func (a adapter) Requires() []Field              { return requires(a.p) }
+func (a adapter) Provides() []Field              { return provides(a.p) }
+
+func (a adapter) fail(item RawItemR, err error) error {
+	path, _ := PathOf(item)
+	return fmt.Errorf("%s: %s: %w", a.Name(), path, err)
+}
+
+// Unwrap returns adapted perceptor
+func (a adapter) Unwrap() Perceptor {
+	return a.p
+}
+
+// adapt runs processor of adapted perceptor in nested chain between two conversions of items.
+// chout is not closed, as BuildChain closes outputs of steps
+func adapt[T any, R any](chin <-chan RawItemR, chout chan<- RawItemR,
+	in func(RawItemR) (T, error),
+	processor func(<-chan T, chan<- R) chain.Processor,
+	out func(<-chan R, chan<- RawItemR) chain.Processor) chain.Processor {
+
+	mid := make(chan T)
+	res := make(chan R)
+	ch := chain.NewChainProcessor(nil)
+	ch.AddStep(chain.WithClose(chain.NewDecorator(chin, mid, decorateFunc[RawItemR, T](in)), func() { close(mid) }))
+	ch.AddStep(chain.WithClose(processor(mid, res), func() { close(res) }))
+	ch.AddStep(out(res, chout))
+	return ch
+}
+
+// decorateFunc is a Decorator as a plain function
+type decorateFunc[Ti any, To any] func(Ti) (To, error)
+
+func (c decorateFunc[Ti, To]) Decorate(item Ti) (To, error) { return c(item) }
+
+func (decorateFunc[Ti, To]) Stop() {}
+
+type rawAdapter struct {
+	adapter
+	raw RawPerceptor
+}
+
+func (a *rawAdapter) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return adapt(chin, chout,
+		func(item RawItemR) (RawDataItemR, error) {
+			if ri, ok := item.(RawDataItemR); ok {
+				return ri, nil
+			}
+			return nil, a.fail(item, ErrNoContent)
+		},
+		func(chin <-chan RawDataItemR, chout chan<- RawDataItemR) chain.Processor {
+			return a.raw.NewProcessor(chin, chout, logger)
+		},
+		func(chin <-chan RawDataItemR, chout chan<- RawItemR) chain.Processor {
+			return chain.NewDecorator(chin, chout, decorateFunc[RawDataItemR, RawItemR](func(item RawDataItemR) (RawItemR, error) {
+				return item, nil
+			}))
+		})
+}
+
+type metadataAdapter struct {
+	adapter
+	metadata MetadataPerceptor
+}
+
+// metadataView shows item as MetadataItemR, changes go to metadata storage of the item it wraps
+type metadataView struct {
+	RawItemR
+	MetadataDataProvider
+	MetadataDataEditor
+}
+
+func (v *metadataView) Unwrap() RawItemR {
+	return v.RawItemR
+}
+
+func (a *metadataAdapter) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return adapt(chin, chout,
+		func(item RawItemR) (MetadataItemR, error) {
+			provider, ok := MetadataOf(item)
+			if !ok {
+				return nil, a.fail(item, ErrNoMetadata)
+			}
+			editor, _ := EditorOf(item, a.Name())
+			me, ok := editor.(MetadataDataEditor)
+			if !ok {
+				return nil, a.fail(item, ErrNoMetadata)
+			}
+			return &metadataView{RawItemR: item, MetadataDataProvider: provider, MetadataDataEditor: me}, nil
+		},
+		func(chin <-chan MetadataItemR, chout chan<- MetadataItemR) chain.Processor {
+			return a.metadata.NewProcessor(chin, chout, logger)
+		},
+		func(chin <-chan MetadataItemR, chout chan<- RawItemR) chain.Processor {
+			// the view is dropped, so the item which came in goes further
+			return chain.NewDecorator(chin, chout, decorateFunc[MetadataItemR, RawItemR](func(item MetadataItemR) (RawItemR, error) {
+				if v, ok := item.(*metadataView); ok {
+					return v.RawItemR, nil
+				}
+				return item, nil
+			}))
+		})
+}
+
+type groupAdapter struct {
+	adapter
+	group GroupPerceptor
+}
+
+func (a *groupAdapter) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return adapt(chin, chout,
+		func(item RawItemR) (RawItemR, error) { return item, nil },
+		func(chin <-chan RawItemR, chout chan<- ItemGroupR) chain.Processor {
+			return a.group.NewGroupProcessor(chin, chout, logger)
+		},
+		func(chin <-chan ItemGroupR, chout chan<- RawItemR) chain.Processor {
+			return chain.NewExpander(chin, chout, &ungrouper{seen: make(map[RawItemR]bool)})
+		})
+}
+
+// ungrouper emits items of groups, every item once even if it belongs to several groups
+type ungrouper struct {
+	seen map[RawItemR]bool
+}
+
+func (u *ungrouper) Expand(group ItemGroupR) ([]RawItemR, error) {
+	var res []RawItemR
+	for _, item := range group.GetItems() {
+		if !u.seen[item] {
+			u.seen[item] = true
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func (u *ungrouper) Stop() {}
@@ -0,0 +1,60 @@
+package api
+
+import "slices"
+
+// CompanionRole tells what companion file is to its primary item
+type CompanionRole string
+
+const (
+	CompanionRaw         CompanionRole = "raw"          // raw file of the same shot
+	CompanionJPEG        CompanionRole = "jpeg"         // jpeg rendered by camera from the same shot
+	CompanionLivePhoto   CompanionRole = "live_photo"   // video of Apple Live Photo
+	CompanionMotionPhoto CompanionRole = "motion_photo" // video embedded into Google or Samsung Motion Photo
+)
+
+// Companion is a file which belongs to the same logical item as the primary one,
+// e.g. jpeg of RAW+JPEG pair or video of Live Photo
+type Companion struct {
+	Role CompanionRole
+	Path string
+	Item RawItemR // nil for embedded companions
+
+	// Embedded companions are stored at the end of the primary file,
+	// Length is their size in bytes, zero if unknown
+	Embedded bool
+	Length   int64
+}
+
+// CompanionProvider is implemented by items which could have companions
+type CompanionProvider interface {
+	GetCompanions() []Companion
+}
+
+type CompanionEditor interface {
+	// AddCompanion attaches companion, companion with the same role and path is replaced
+	AddCompanion(c Companion)
+}
+
+// CompanionsOf returns companions of the item, or of the item it wraps
+func CompanionsOf(item RawItemR) []Companion {
+	for item != nil {
+		if cp, ok := item.(CompanionProvider); ok {
+			return cp.GetCompanions()
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil
+}
+
+func addCompanion(companions []Companion, c Companion) []Companion {
+	i := slices.IndexFunc(companions, func(o Companion) bool { return o.Role == c.Role && o.Path == c.Path })
+	if i >= 0 {
+		companions[i] = c
+		return companions
+	}
+	return append(companions, c)
+}
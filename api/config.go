@@ -0,0 +1,437 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigType is a type of config field value
+type ConfigType string
+
+const (
+	ConfigString   ConfigType = "string"
+	ConfigInt      ConfigType = "int"
+	ConfigFloat    ConfigType = "float"
+	ConfigBool     ConfigType = "bool"
+	ConfigDuration ConfigType = "duration" // "1m30s" in text, seconds as a number
+	ConfigStrings  ConfigType = "strings"  // comma separated in text
+)
+
+// ConfigField describes single setting of a perceptor
+type ConfigField struct {
+	Name        string
+	Type        ConfigType
+	Description string
+	Default     any      // value of the field type, nil makes field empty
+	Required    bool     // field must be set explicitly
+	Min, Max    *float64 // inclusive bounds of numbers, durations in seconds and count of strings
+	Enum        []string // allowed values of strings
+}
+
+// Limit is a helper to set ConfigField bounds
+func Limit(v float64) *float64 {
+	return &v
+}
+
+// ConfigSchema is a set of settings of a perceptor
+type ConfigSchema []ConfigField
+
+// Configurable is an optional Perceptor extension for perceptors with settings.
+// Configure receives config parsed by ConfigSchema, so only values which depend on each other should be checked.
+// Clone returns copy of the perceptor which could be configured without changing the original, see Configured
+type Configurable interface {
+	ConfigSchema() ConfigSchema
+	Configure(cfg Config) error
+	Clone() Perceptor
+}
+
+// ConfigError tells which setting of which perceptor is wrong
+type ConfigError struct {
+	Perceptor string
+	Field     string
+	Err       error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("config of %s: %v", e.Perceptor, e.Err)
+	}
+	return fmt.Sprintf("config of %s: %s: %v", e.Perceptor, e.Field, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Config is a validated set of values, every field with value or default is present with value of its type
+type Config map[string]any
+
+func (c Config) String(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+func (c Config) Int(name string) int64 {
+	v, _ := c[name].(int64)
+	return v
+}
+
+func (c Config) Float(name string) float64 {
+	v, _ := c[name].(float64)
+	return v
+}
+
+func (c Config) Bool(name string) bool {
+	v, _ := c[name].(bool)
+	return v
+}
+
+func (c Config) Duration(name string) time.Duration {
+	v, _ := c[name].(time.Duration)
+	return v
+}
+
+func (c Config) Strings(name string) []string {
+	v, _ := c[name].([]string)
+	return slices.Clone(v)
+}
+
+// Field returns field with given name
+func (s ConfigSchema) Field(name string) (ConfigField, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return ConfigField{}, false
+}
+
+// Parse converts raw values from json or environment, validates them and applies defaults.
+// Unknown fields are errors, so typos don't pass silently
+func (s ConfigSchema) Parse(raw map[string]any) (Config, error) {
+	var errs []error
+	for name := range raw {
+		if _, ok := s.Field(name); !ok {
+			errs = append(errs, &ConfigError{Field: name, Err: fmt.Errorf("%w: unknown field", ErrInvalidConfig)})
+		}
+	}
+
+	cfg := make(Config, len(s))
+	for _, f := range s {
+		value, ok := raw[f.Name]
+		if !ok || value == nil {
+			if f.Required {
+				errs = append(errs, &ConfigError{Field: f.Name, Err: fmt.Errorf("%w: required", ErrInvalidConfig)})
+				continue
+			}
+			value = f.Default
+		}
+		if value == nil {
+			continue
+		}
+
+		v, err := f.parse(value)
+		if err != nil {
+			errs = append(errs, &ConfigError{Field: f.Name, Err: err})
+			continue
+		}
+		cfg[f.Name] = v
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+func (f ConfigField) parse(value any) (any, error) {
+	v, err := convert(f.Type, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := f.check(v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return v, nil
+}
+
+func (f ConfigField) check(v any) error {
+	var n float64
+	switch x := v.(type) {
+	case int64:
+		n = float64(x)
+	case float64:
+		n = x
+	case time.Duration:
+		n = x.Seconds()
+	case []string:
+		n = float64(len(x))
+		for _, s := range x {
+			if len(f.Enum) > 0 && !slices.Contains(f.Enum, s) {
+				return fmt.Errorf("%q is not one of %s", s, strings.Join(f.Enum, ", "))
+			}
+		}
+	case string:
+		if len(f.Enum) > 0 && !slices.Contains(f.Enum, x) {
+			return fmt.Errorf("%q is not one of %s", x, strings.Join(f.Enum, ", "))
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if f.Min != nil && n < *f.Min {
+		return fmt.Errorf("%v is less than %v", v, *f.Min)
+	}
+	if f.Max != nil && n > *f.Max {
+		return fmt.Errorf("%v is greater than %v", v, *f.Max)
+	}
+	return nil
+}
+
+// convert accepts values of the type itself, json decoded values and text
+func convert(t ConfigType, value any) (any, error) {
+	if s, ok := value.(string); ok && t != ConfigString {
+		return convertText(t, s)
+	}
+
+	switch t {
+	case ConfigString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case ConfigInt:
+		switch x := value.(type) {
+		case int:
+			return int64(x), nil
+		case int64:
+			return x, nil
+		case float64:
+			if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+				return int64(x), nil
+			}
+		case json.Number:
+			return x.Int64()
+		}
+	case ConfigFloat:
+		switch x := value.(type) {
+		case int:
+			return float64(x), nil
+		case int64:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		case json.Number:
+			return x.Float64()
+		}
+	case ConfigBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case ConfigDuration:
+		switch x := value.(type) {
+		case time.Duration:
+			return x, nil
+		case int:
+			return seconds(float64(x))
+		case int64:
+			return seconds(float64(x))
+		case float64:
+			return seconds(x)
+		case json.Number:
+			f, err := x.Float64()
+			if err != nil {
+				return nil, err
+			}
+			return seconds(f)
+		}
+	case ConfigStrings:
+		switch x := value.(type) {
+		case []string:
+			return slices.Clone(x), nil
+		case []any:
+			res := make([]string, 0, len(x))
+			for _, e := range x {
+				s, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("%v is not a string", e)
+				}
+				res = append(res, s)
+			}
+			return res, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", t)
+	}
+	return nil, fmt.Errorf("%v is not %s", value, t)
+}
+
+func seconds(x float64) (time.Duration, error) {
+	d := x * float64(time.Second)
+	if math.IsNaN(d) || math.Abs(d) >= math.MaxInt64 {
+		return 0, fmt.Errorf("%v seconds is out of range", x)
+	}
+	return time.Duration(d), nil
+}
+
+func convertText(t ConfigType, s string) (any, error) {
+	s = strings.TrimSpace(s)
+	switch t {
+	case ConfigInt:
+		return strconv.ParseInt(s, 10, 64)
+	case ConfigFloat:
+		return strconv.ParseFloat(s, 64)
+	case ConfigBool:
+		return strconv.ParseBool(s)
+	case ConfigDuration:
+		return time.ParseDuration(s)
+	case ConfigStrings:
+		if s == "" {
+			return []string{}, nil
+		}
+		res := strings.Split(s, ",")
+		for i := range res {
+			res[i] = strings.TrimSpace(res[i])
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", t)
+}
+
+// ConfigSet keeps raw settings of perceptors keyed by perceptor name
+type ConfigSet map[string]map[string]any
+
+// ParseConfigJSON reads settings like {"exif_size": {"snap_ratio": true}}
+func ParseConfigJSON(data []byte) (ConfigSet, error) {
+	var cs ConfigSet
+	if err := json.Unmarshal(data, &cs); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return cs, nil
+}
+
+// ConfigFromEnv reads settings of configurable perceptors from environment variables
+// named PREFIX_PERCEPTOR_FIELD in upper case, e.g. PERCEPLIB_EXIF_SIZE_SNAP_RATIO
+func ConfigFromEnv[P Perceptor](prefix string, perceptors []P) ConfigSet {
+	cs := make(ConfigSet)
+	for _, p := range perceptors {
+		c, ok := any(p).(Configurable)
+		if !ok {
+			continue
+		}
+		for _, f := range c.ConfigSchema() {
+			name := strings.ToUpper(strings.Join([]string{prefix, p.Name(), f.Name}, "_"))
+			if prefix == "" {
+				name = strings.TrimPrefix(name, "_")
+			}
+			if v, ok := os.LookupEnv(name); ok {
+				if cs[p.Name()] == nil {
+					cs[p.Name()] = make(map[string]any)
+				}
+				cs[p.Name()][f.Name] = v
+			}
+		}
+	}
+	return cs
+}
+
+// Merge returns settings of both sets, values of other set take precedence
+func (cs ConfigSet) Merge(other ConfigSet) ConfigSet {
+	res := make(ConfigSet, len(cs))
+	for _, set := range []ConfigSet{cs, other} {
+		for name, fields := range set {
+			if res[name] == nil {
+				res[name] = make(map[string]any, len(fields))
+			}
+			for k, v := range fields {
+				res[name][k] = v
+			}
+		}
+	}
+	return res
+}
+
+// ConfigurePerceptors parses settings of every configurable perceptor and applies them.
+// Perceptors without settings get defaults, all errors are returned together.
+// Given perceptors are changed, use Configured for perceptors shared with others, e.g. registered ones
+func ConfigurePerceptors[P Perceptor](perceptors []P, set ConfigSet) error {
+	var errs []error
+	known := make(map[string]bool, len(perceptors))
+	for _, p := range perceptors {
+		known[p.Name()] = true
+
+		c, ok := any(p).(Configurable)
+		if !ok {
+			if len(set[p.Name()]) > 0 {
+				errs = append(errs, &ConfigError{Perceptor: p.Name(), Err: fmt.Errorf("%w: perceptor has no settings", ErrInvalidConfig)})
+			}
+			continue
+		}
+
+		cfg, err := c.ConfigSchema().Parse(set[p.Name()])
+		if err == nil {
+			err = c.Configure(cfg)
+		}
+		if err != nil {
+			errs = append(errs, withPerceptor(p.Name(), err))
+		}
+	}
+
+	for name := range set {
+		if !known[name] {
+			errs = append(errs, &ConfigError{Perceptor: name, Err: fmt.Errorf("%w: unknown perceptor", ErrInvalidConfig)})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withPerceptor fills perceptor name of errors produced by ConfigSchema.Parse and Configure
+func withPerceptor(perceptor string, err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		res := make([]error, len(errs))
+		for i, e := range errs {
+			res[i] = withPerceptor(perceptor, e)
+		}
+		return errors.Join(res...)
+	}
+
+	if ce, ok := err.(*ConfigError); ok {
+		if ce.Perceptor == "" {
+			ce.Perceptor = perceptor
+		}
+		return ce
+	}
+	return &ConfigError{Perceptor: perceptor, Err: err}
+}
+
+// Configured returns perceptors with applied settings, configurable ones are replaced by their configured clones
+// and given perceptors are left as is, see ConfigurePerceptors
+func Configured[P Perceptor](perceptors []P, set ConfigSet) ([]P, error) {
+	res := make([]P, len(perceptors))
+	for i, p := range perceptors {
+		res[i] = p
+		c, ok := any(p).(Configurable)
+		if !ok {
+			continue
+		}
+		clone := c.Clone()
+		if res[i], ok = clone.(P); !ok {
+			return nil, &ConfigError{Perceptor: p.Name(), Err: fmt.Errorf("%w: clone is %T", ErrInvalidConfig, clone)}
+		}
+	}
+
+	if err := ConfigurePerceptors(res, set); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+type configurablePerceptor struct {
+	mockPerceptor
+	cfg Config
+}
+
+func (c *configurablePerceptor) ConfigSchema() ConfigSchema {
+	return ConfigSchema{
+		{Name: "threshold", Type: ConfigFloat, Default: 0.5, Min: Limit(0), Max: Limit(1)},
+		{Name: "count", Type: ConfigInt, Default: 3},
+		{Name: "mode", Type: ConfigString, Default: "fast", Enum: []string{"fast", "exact"}},
+		{Name: "timeout", Type: ConfigDuration, Default: time.Second},
+		{Name: "labels", Type: ConfigStrings},
+		{Name: "model", Type: ConfigString, Required: true},
+	}
+}
+
+func (c *configurablePerceptor) Configure(cfg Config) error {
+	c.cfg = cfg
+	return nil
+}
+
+func (c *configurablePerceptor) Clone() Perceptor {
+	clone := *c
+	return &clone
+}
+
+func (c *configurablePerceptor) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, passThrough{})
+}
+
+func TestConfigSchemaParse(t *testing.T) {
+	schema := (&configurablePerceptor{}).ConfigSchema()
+
+	t.Run("defaults and conversion", func(t *testing.T) {
+		set, err := ParseConfigJSON([]byte(`{"p": {"count": 5, "timeout": "2m", "labels": ["a", "b"], "model": "m"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := schema.Parse(set["p"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.Float("threshold") != 0.5 || cfg.Int("count") != 5 || cfg.String("mode") != "fast" ||
+			cfg.Duration("timeout") != 2*time.Minute || len(cfg.Strings("labels")) != 2 || cfg.String("model") != "m" {
+			t.Errorf("unexpected config %v", cfg)
+		}
+	})
+
+	t.Run("duration as a number of seconds", func(t *testing.T) {
+		set, err := ParseConfigJSON([]byte(`{"a": {"timeout": 90, "model": "m"}, "b": {"timeout": 1.5, "model": "m"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]time.Duration{"a": 90 * time.Second, "b": 1500 * time.Millisecond}
+		for name, d := range expected {
+			cfg, err := schema.Parse(set[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Duration("timeout") != d {
+				t.Errorf("%s: expected %v, got %v", name, d, cfg.Duration("timeout"))
+			}
+		}
+
+		cfg, err := schema.Parse(map[string]any{"timeout": 2, "model": "m"})
+		if err != nil || cfg.Duration("timeout") != 2*time.Second {
+			t.Errorf("expected 2s, got %v, %v", cfg.Duration("timeout"), err)
+		}
+		if _, err := schema.Parse(map[string]any{"timeout": 1e12, "model": "m"}); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("expected ErrInvalidConfig for duration out of range, got %v", err)
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		tests := map[string]map[string]any{
+			"threshold": {"threshold": 1.5, "model": "m"},
+			"count":     {"count": 1.5, "model": "m"},
+			"mode":      {"mode": "slow", "model": "m"},
+			"timeout":   {"timeout": "soon", "model": "m"},
+			"model":     {},
+			"unknown":   {"unknown": 1, "model": "m"},
+		}
+		for field, raw := range tests {
+			_, err := schema.Parse(raw)
+			var ce *ConfigError
+			if !errors.Is(err, ErrInvalidConfig) || !errors.As(err, &ce) || ce.Field != field {
+				t.Errorf("%s: expected config error, got %v", field, err)
+			}
+		}
+	})
+}
+
+func TestConfigurePerceptors(t *testing.T) {
+	p := &configurablePerceptor{mockPerceptor: mockPerceptor{name: "p"}}
+	plain := &mockPerceptor{name: "plain"}
+
+	t.Setenv("PL_P_MODEL", "env")
+	t.Setenv("PL_P_COUNT", "7")
+	set := ConfigSet{"p": {"model": "json", "mode": "exact"}}.Merge(ConfigFromEnv("pl", []Perceptor{p, plain}))
+
+	if err := ConfigurePerceptors([]Perceptor{p, plain}, set); err != nil {
+		t.Fatal(err)
+	}
+	if p.cfg.String("model") != "env" || p.cfg.String("mode") != "exact" || p.cfg.Int("count") != 7 {
+		t.Errorf("unexpected config %v", p.cfg)
+	}
+
+	err := ConfigurePerceptors([]Perceptor{p, plain}, ConfigSet{
+		"p":       {"count": "many"},
+		"plain":   {"a": 1},
+		"missing": {"a": 1},
+	})
+	var ce *ConfigError
+	if !errors.As(err, &ce) || ce.Perceptor == "" {
+		t.Errorf("expected config errors with perceptor names, got %v", err)
+	}
+	for _, text := range []string{"config of p: count", "config of p: model", "config of plain", "config of missing"} {
+		if err == nil || !strings.Contains(err.Error(), text) {
+			t.Errorf("expected %q in %v", text, err)
+		}
+	}
+}
+
+func TestConfigured(t *testing.T) {
+	p := &configurablePerceptor{mockPerceptor: mockPerceptor{name: "p"}}
+	plain := &mockPerceptor{name: "plain"}
+
+	res, err := Configured([]Perceptor{p, plain}, ConfigSet{"p": {"model": "m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.cfg != nil {
+		t.Error("given perceptor shouldn't be configured")
+	}
+	if c, ok := res[0].(*configurablePerceptor); !ok || c == p || c.cfg.String("model") != "m" {
+		t.Errorf("expected configured clone, got %+v", res[0])
+	}
+	if res[1] != plain {
+		t.Error("perceptor without settings should be kept")
+	}
+
+	if _, err := Configured([]Perceptor{p}, ConfigSet{}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import "strings"
+
+// SplitExifKey splits group qualified key "QuickTime:CreateDate", group is empty for unqualified keys
+func SplitExifKey(key string) (group, tag string) {
+	if group, tag, ok := strings.Cut(key, ":"); ok {
+		return group, tag
+	}
+	return "", key
+}
+
+// lookup returns value of the key. Keys are kept as exiftool printed them,
+// bare tag names of group qualified keys are resolved with index, see exiftool.Index
+func (r RawExif) lookup(index map[string]string, key string) (string, bool) {
+	if v, ok := r[key]; ok {
+		return string(v), true
+	}
+	if k, ok := index[key]; ok {
+		v, ok := r[k]
+		return string(v), ok
+	}
+	return "", false
+}
+
+// tags returns bare tag names of all keys
+func (r RawExif) tags() map[string]bool {
+	res := make(map[string]bool, len(r))
+	for k := range r {
+		_, tag := SplitExifKey(k)
+		res[tag] = true
+	}
+	return res
+}
@@ -0,0 +1,418 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrExifMissing   = errors.New("exif value is missing")
+	ErrExifMalformed = errors.New("exif value is malformed")
+)
+
+// ExifError describes which tag and value couldn't be converted
+type ExifError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *ExifError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("exif %s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("exif %s: %v: %q", e.Key, e.Err, e.Value)
+}
+
+func (e *ExifError) Unwrap() error {
+	return e.Err
+}
+
+func missing(key string) error {
+	return &ExifError{Key: key, Err: ErrExifMissing}
+}
+
+func malformed(key, value string) error {
+	return &ExifError{Key: key, Value: value, Err: ErrExifMalformed}
+}
+
+// Rational is an exif rational number, e.g. exposure time 1/200
+type Rational struct {
+	Num int64
+	Den int64
+}
+
+func (r Rational) Float() float64 {
+	if r.Den == 0 {
+		return math.NaN()
+	}
+	return float64(r.Num) / float64(r.Den)
+}
+
+func (r Rational) String() string {
+	if r.Den == 1 {
+		return strconv.FormatInt(r.Num, 10)
+	}
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
+// ExifString returns trimmed value with given key
+func ExifString(p ExifProvider, key string) (string, error) {
+	v := strings.TrimSpace(p.GetExif(key))
+	if v == "" {
+		return "", missing(key)
+	}
+	return v, nil
+}
+
+// firstField takes numeric part of values with units, like "50.0 mm"
+func firstField(v string) string {
+	if i := strings.IndexAny(v, " \t"); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
+
+// ExifInt returns integer value, units after the number are ignored
+func ExifInt(p ExifProvider, key string) (int64, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(firstField(v), 10, 64)
+	if err != nil {
+		return 0, malformed(key, v)
+	}
+	return i, nil
+}
+
+// ExifFloat returns float value, both decimal "2.8" and rational "1/200" forms are accepted,
+// units after the number are ignored
+func ExifFloat(p ExifProvider, key string) (float64, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := parseFloat(firstField(v))
+	if err != nil {
+		return 0, malformed(key, v)
+	}
+	return f, nil
+}
+
+func parseFloat(v string) (float64, error) {
+	if num, den, ok := strings.Cut(v, "/"); ok {
+		r, err := parseRational(num, den)
+		if err != nil {
+			return 0, err
+		}
+		return r.Float(), nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrExifMalformed
+	}
+	return f, nil
+}
+
+func parseRational(num, den string) (Rational, error) {
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return Rational{}, err
+	}
+	d, err := strconv.ParseInt(den, 10, 64)
+	if err != nil || d == 0 {
+		return Rational{}, ErrExifMalformed
+	}
+	return Rational{Num: n, Den: d}, nil
+}
+
+// ExifRational returns rational value, decimal values are converted to exact fraction
+func ExifRational(p ExifProvider, key string) (Rational, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return Rational{}, err
+	}
+
+	field := firstField(v)
+	if num, den, ok := strings.Cut(field, "/"); ok {
+		r, err := parseRational(num, den)
+		if err != nil {
+			return Rational{}, malformed(key, v)
+		}
+		return r, nil
+	}
+
+	whole, frac, _ := strings.Cut(field, ".")
+	if len(frac) > 9 {
+		frac = frac[:9]
+	}
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Rational{}, malformed(key, v)
+	}
+	d := int64(math.Pow10(len(frac)))
+	g := int64(gcd(int(abs(n)), int(d)))
+	return Rational{Num: n / g, Den: d / g}, nil
+}
+
+func abs(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+var exifTimeRe = regexp.MustCompile(`^(\d{4}):(\d{2}):(\d{2})(?:[ T](\d{2}):(\d{2})(?::(\d{2}))?)?(?:\.(\d+))?\s*(Z|[+-]\d{2}:?\d{2})?(?:\s*DST)?$`)
+
+// ParseExifTime parses exif date "2006:01:02 15:04:05" with optional sub seconds and offset.
+// Values without offset are placed into loc, hasZone reports whether offset was present
+func ParseExifTime(value string, loc *time.Location) (t time.Time, hasZone bool, err error) {
+	m := exifTimeRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return time.Time{}, false, ErrExifMalformed
+	}
+
+	num := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	year, month, day := num(m[1]), num(m[2]), num(m[3])
+	hour, min, sec := num(m[4]), num(m[5]), num(m[6])
+	if year == 0 || month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || min > 59 || sec > 60 {
+		return time.Time{}, false, ErrExifMalformed
+	}
+
+	nsec := 0
+	if m[7] != "" {
+		nsec = num((m[7] + "000000000")[:9])
+	}
+
+	if m[8] != "" {
+		offset, err := parseOffset(m[8])
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = offset
+		hasZone = true
+	} else if loc == nil {
+		loc = time.UTC
+	}
+
+	t = time.Date(year, time.Month(month), day, hour, min, sec, nsec, loc)
+	if t.Day() != day {
+		// e.g. 2023:02:30 normalized into March
+		return time.Time{}, false, ErrExifMalformed
+	}
+	return t, hasZone, nil
+}
+
+// ParseExifOffset parses time zone offset like "+02:00", "-0500" or "Z"
+func ParseExifOffset(value string) (*time.Location, error) {
+	return parseOffset(strings.TrimSpace(value))
+}
+
+func parseOffset(v string) (*time.Location, error) {
+	if v == "Z" {
+		return time.UTC, nil
+	}
+	if len(v) < 5 || (v[0] != '+' && v[0] != '-') {
+		return nil, ErrExifMalformed
+	}
+
+	digits := strings.ReplaceAll(v[1:], ":", "")
+	if len(digits) != 4 {
+		return nil, ErrExifMalformed
+	}
+	h, err1 := strconv.Atoi(digits[:2])
+	m, err2 := strconv.Atoi(digits[2:])
+	if err1 != nil || err2 != nil || h > 14 || m > 59 {
+		return nil, ErrExifMalformed
+	}
+
+	sec := h*3600 + m*60
+	if v[0] == '-' {
+		sec = -sec
+	}
+	if sec == 0 {
+		return time.UTC, nil
+	}
+	return time.FixedZone(v[:1]+digits[:2]+":"+digits[2:], sec), nil
+}
+
+// ExifTime returns date value, values without offset are treated as UTC
+func ExifTime(p ExifProvider, key string) (time.Time, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, _, err := ParseExifTime(v, time.UTC)
+	if err != nil {
+		return time.Time{}, malformed(key, v)
+	}
+	return t, nil
+}
+
+// ExifDateTime combines date with separate sub seconds and offset tags,
+// like DateTimeOriginal + SubSecTimeOriginal + OffsetTimeOriginal.
+// subSecKey and offsetKey are optional, offset embedded into date wins.
+// Values without any offset are placed into loc, hasZone reports whether offset was found
+func ExifDateTime(p ExifProvider, dateKey, subSecKey, offsetKey string, loc *time.Location) (t time.Time, hasZone bool, err error) {
+	v, err := ExifString(p, dateKey)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	t, hasZone, err = ParseExifTime(v, loc)
+	if err != nil {
+		return time.Time{}, false, malformed(dateKey, v)
+	}
+
+	if subSecKey != "" && t.Nanosecond() == 0 {
+		if ss, err := ExifString(p, subSecKey); err == nil {
+			// digits only, Atoi accepts sign which would shift the time back
+			if strings.Trim(ss, "0123456789") != "" {
+				return time.Time{}, false, malformed(subSecKey, ss)
+			}
+			nsec, _ := strconv.Atoi((ss + "000000000")[:9])
+			t = t.Add(time.Duration(nsec))
+		}
+	}
+
+	if offsetKey != "" && !hasZone {
+		if off, err := ExifString(p, offsetKey); err == nil {
+			zone, err := parseOffset(off)
+			if err != nil {
+				return time.Time{}, false, malformed(offsetKey, off)
+			}
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), zone)
+			hasZone = true
+		}
+	}
+
+	return t, hasZone, nil
+}
+
+var dmsRe = regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)(?:\s*deg\s*(\d+(?:\.\d+)?)?'?\s*(?:(\d+(?:\.\d+)?)")?)?\s*([NSEW])?$`)
+
+// ParseCoordinate parses coordinate in "37 deg 46' 29.64\" N" or decimal "-37.7749" form
+func ParseCoordinate(value string) (float64, error) {
+	m := dmsRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, ErrExifMalformed
+	}
+
+	deg, _ := strconv.ParseFloat(m[1], 64)
+	var min, sec float64
+	if m[2] != "" {
+		min, _ = strconv.ParseFloat(m[2], 64)
+	}
+	if m[3] != "" {
+		sec, _ = strconv.ParseFloat(m[3], 64)
+	}
+	if min >= 60 || sec >= 60 {
+		return 0, ErrExifMalformed
+	}
+
+	sign := 1.0
+	if deg < 0 || strings.HasPrefix(m[1], "-") {
+		sign = -1
+		deg = -deg
+	}
+	if m[4] == "S" || m[4] == "W" {
+		sign = -sign
+	}
+
+	return sign * (deg + min/60 + sec/3600), nil
+}
+
+func coordinate(p ExifProvider, key, refKey string, limit float64) (float64, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := ParseCoordinate(v)
+	if err != nil || math.Abs(c) > limit {
+		return 0, malformed(key, v)
+	}
+
+	if ref, err := ExifString(p, refKey); err == nil {
+		switch strings.ToUpper(ref[:1]) {
+		case "S", "W":
+			c = -math.Abs(c)
+		case "N", "E":
+			c = math.Abs(c)
+		default:
+			return 0, malformed(refKey, ref)
+		}
+	}
+	return c, nil
+}
+
+// ExifGPS returns location from GPSLatitude, GPSLongitude and GPSAltitude with their reference tags
+func ExifGPS(p ExifProvider) (Location, error) {
+	lat, err := coordinate(p, "GPSLatitude", "GPSLatitudeRef", 90)
+	if err != nil {
+		return Location{}, err
+	}
+	lon, err := coordinate(p, "GPSLongitude", "GPSLongitudeRef", 180)
+	if err != nil {
+		return Location{}, err
+	}
+
+	loc := Location{Latitude: lat, Longitude: lon}
+
+	if alt, err := ExifFloat(p, "GPSAltitude"); err == nil {
+		v := strings.ToLower(p.GetExif("GPSAltitude") + " " + p.GetExif("GPSAltitudeRef"))
+		if strings.Contains(v, "below") || strings.TrimSpace(p.GetExif("GPSAltitudeRef")) == "1" {
+			alt = -math.Abs(alt)
+		}
+		loc.Altitude = alt
+	}
+
+	return loc, nil
+}
+
+// ExifList returns list value, exiftool joins list items with ", "
+func ExifList(p ExifProvider, key string) ([]string, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(v, ",")
+	res := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res, nil
+}
+
+// ExifBool returns boolean value, accepts Yes/No, True/False, On/Off and 1/0
+func ExifBool(p ExifProvider, key string) (bool, error) {
+	v, err := ExifString(p, key)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(v) {
+	case "yes", "true", "on", "1":
+		return true, nil
+	case "no", "false", "off", "0":
+		return false, nil
+	}
+	return false, malformed(key, v)
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func exifOf(kv ...string) RawExif {
+	exif := make(RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+func TestExifNumbers(t *testing.T) {
+	exif := exifOf(
+		"ISO", "400",
+		"FocalLength", "50.0 mm",
+		"ExposureTime", "1/200",
+		"FNumber", "2.8",
+		"Orientation", "Rotate 90 CW",
+	)
+
+	if v, err := ExifInt(exif, "ISO"); err != nil || v != 400 {
+		t.Errorf("ISO: got %v, %v", v, err)
+	}
+	if v, err := ExifFloat(exif, "FocalLength"); err != nil || v != 50 {
+		t.Errorf("FocalLength: got %v, %v", v, err)
+	}
+	if v, err := ExifFloat(exif, "ExposureTime"); err != nil || v != 0.005 {
+		t.Errorf("ExposureTime: got %v, %v", v, err)
+	}
+	if v, err := ExifRational(exif, "ExposureTime"); err != nil || v != (Rational{1, 200}) {
+		t.Errorf("ExposureTime rational: got %v, %v", v, err)
+	}
+	if v, err := ExifRational(exif, "FNumber"); err != nil || v != (Rational{14, 5}) {
+		t.Errorf("FNumber rational: got %v, %v", v, err)
+	}
+
+	if _, err := ExifInt(exif, "Orientation"); !errors.Is(err, ErrExifMalformed) {
+		t.Errorf("expected ErrExifMalformed, got %v", err)
+	}
+	if _, err := ExifFloat(exif, "Missing"); !errors.Is(err, ErrExifMissing) {
+		t.Errorf("expected ErrExifMissing, got %v", err)
+	}
+
+	var exifErr *ExifError
+	if _, err := ExifInt(exif, "Orientation"); !errors.As(err, &exifErr) || exifErr.Key != "Orientation" {
+		t.Errorf("expected ExifError for Orientation, got %v", err)
+	}
+}
+
+func TestParseExifTime(t *testing.T) {
+	kyiv := time.FixedZone("+03:00", 3*3600)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		hasZone  bool
+		err      bool
+	}{
+		{"2024:06:01 10:20:30", time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC), false, false},
+		{"2024:06:01 10:20:30.45", time.Date(2024, 6, 1, 10, 20, 30, 450000000, time.UTC), false, false},
+		{"2024:06:01 10:20:30+03:00", time.Date(2024, 6, 1, 10, 20, 30, 0, kyiv), true, false},
+		{"2024:06:01 10:20:30.123-0500", time.Date(2024, 6, 1, 15, 20, 30, 123000000, time.UTC), true, false},
+		{"2024:06:01 10:20:30Z", time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC), true, false},
+		{"2024:06:01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), false, false},
+		{"0000:00:00 00:00:00", time.Time{}, false, true},
+		{"2023:02:30 10:00:00", time.Time{}, false, true},
+		{"yesterday", time.Time{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, hasZone, err := ParseExifTime(tt.value, time.UTC)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.expected) || hasZone != tt.hasZone {
+				t.Errorf("expected %v (zone %v), got %v (zone %v)", tt.expected, tt.hasZone, got, hasZone)
+			}
+		})
+	}
+}
+
+func TestExifDateTime(t *testing.T) {
+	exif := exifOf(
+		"DateTimeOriginal", "2024:06:01 10:20:30",
+		"SubSecTimeOriginal", "07",
+		"OffsetTimeOriginal", "+02:00",
+	)
+
+	got, hasZone, err := ExifDateTime(exif, "DateTimeOriginal", "SubSecTimeOriginal", "OffsetTimeOriginal", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2024, 6, 1, 8, 20, 30, 70000000, time.UTC)
+	if !hasZone || !got.Equal(expected) {
+		t.Errorf("expected %v, got %v (zone %v)", expected, got, hasZone)
+	}
+	if _, offset := got.Zone(); offset != 2*3600 {
+		t.Errorf("expected +02:00 offset, got %d", offset)
+	}
+
+	for _, ss := range []string{"-1", "+5", "1a"} {
+		exif := exifOf("DateTimeOriginal", "2024:06:01 10:20:30", "SubSecTimeOriginal", ss)
+		if _, _, err := ExifDateTime(exif, "DateTimeOriginal", "SubSecTimeOriginal", "", time.UTC); !errors.Is(err, ErrExifMalformed) {
+			t.Errorf("%q: expected ErrExifMalformed, got %v", ss, err)
+		}
+	}
+
+	exif["OffsetTimeOriginal"] = []byte("local")
+	if _, _, err := ExifDateTime(exif, "DateTimeOriginal", "SubSecTimeOriginal", "OffsetTimeOriginal", time.UTC); !errors.Is(err, ErrExifMalformed) {
+		t.Errorf("expected ErrExifMalformed, got %v", err)
+	}
+}
+
+func TestExifGPS(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+	t.Run("printed form", func(t *testing.T) {
+		exif := exifOf(
+			"GPSLatitude", `37 deg 46' 29.64" N`,
+			"GPSLatitudeRef", "North",
+			"GPSLongitude", `122 deg 25' 9.84" W`,
+			"GPSLongitudeRef", "West",
+			"GPSAltitude", "12.5 m Below Sea Level",
+		)
+
+		loc, err := ExifGPS(exif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !near(loc.Latitude, 37.7749) || !near(loc.Longitude, -122.4194) || loc.Altitude != -12.5 {
+			t.Errorf("unexpected location %+v", loc)
+		}
+	})
+
+	t.Run("numeric form", func(t *testing.T) {
+		exif := exifOf(
+			"GPSLatitude", "33.8688",
+			"GPSLatitudeRef", "S",
+			"GPSLongitude", "151.2093",
+			"GPSLongitudeRef", "E",
+			"GPSAltitude", "58",
+			"GPSAltitudeRef", "0",
+		)
+
+		loc, err := ExifGPS(exif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !near(loc.Latitude, -33.8688) || !near(loc.Longitude, 151.2093) || loc.Altitude != 58 {
+			t.Errorf("unexpected location %+v", loc)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		if _, err := ExifGPS(exifOf("GPSLatitude", "95", "GPSLongitude", "10")); !errors.Is(err, ErrExifMalformed) {
+			t.Errorf("expected ErrExifMalformed, got %v", err)
+		}
+		if _, err := ExifGPS(exifOf("GPSLatitude", "45")); !errors.Is(err, ErrExifMissing) {
+			t.Errorf("expected ErrExifMissing, got %v", err)
+		}
+	})
+}
+
+func TestExifListAndBool(t *testing.T) {
+	exif := exifOf(
+		"Subject", "sea, sunset,  beach",
+		"FlashFired", "True",
+		"Compressed", "maybe",
+	)
+
+	list, err := ExifList(exif, "Subject")
+	if err != nil || len(list) != 3 || list[2] != "beach" {
+		t.Errorf("unexpected list %v, %v", list, err)
+	}
+
+	if v, err := ExifBool(exif, "FlashFired"); err != nil || !v {
+		t.Errorf("FlashFired: got %v, %v", v, err)
+	}
+	if _, err := ExifBool(exif, "Compressed"); !errors.Is(err, ErrExifMalformed) {
+		t.Errorf("expected ErrExifMalformed, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNoGuid            = errors.New("no guid source found")
+	ErrUnsupportedFormat = errors.New("unsupported file format")
+)
+
+// GuidStrategy derives identity of the item which stays stable when file is renamed or moved
+type GuidStrategy interface {
+	Guid(item RawItemR) (string, error)
+}
+
+// GuidFunc is a GuidStrategy as a plain function
+type GuidFunc func(item RawItemR) (string, error)
+
+func (f GuidFunc) Guid(item RawItemR) (string, error) {
+	return f(item)
+}
+
+// DefaultGuidStrategy prefers image data hashes, either computed by exiftool or from file contents,
+// and falls back to unique ids written by camera or editor
+var DefaultGuidStrategy = FirstGuid(ImageDataHashGuid(), ContentGuid(), ExifIDGuid("ImageUniqueID", "DocumentID"))
+
+// AssignGuid sets guid produced by the strategy to the item
+func AssignGuid(item RawItemR, strategy GuidStrategy, perceptor string) error {
+	guid, err := strategy.Guid(item)
+	if err != nil {
+		return err
+	}
+
+	editor, ok := EditorOf(item, perceptor)
+	if !ok {
+		return errors.New("item is read only")
+	}
+	ge, ok := editor.(GuidEditor)
+	if !ok {
+		return errors.New("item doesn't support guid changes")
+	}
+	ge.SetGuid(guid)
+	return nil
+}
+
+// FirstGuid takes guid from the first strategy which succeeds
+func FirstGuid(strategies ...GuidStrategy) GuidStrategy {
+	return GuidFunc(func(item RawItemR) (string, error) {
+		var errs []error
+		for _, s := range strategies {
+			guid, err := s.Guid(item)
+			if err == nil {
+				return guid, nil
+			}
+			errs = append(errs, err)
+		}
+		return "", errors.Join(append([]error{ErrNoGuid}, errs...)...)
+	})
+}
+
+// CombinedGuid hashes results of all strategies together, fails if any of them fails
+func CombinedGuid(strategies ...GuidStrategy) GuidStrategy {
+	return GuidFunc(func(item RawItemR) (string, error) {
+		h := sha256.New()
+		for _, s := range strategies {
+			guid, err := s.Guid(item)
+			if err != nil {
+				return "", err
+			}
+			io.WriteString(h, guid)
+			h.Write([]byte{0})
+		}
+		return "mix:" + hex.EncodeToString(h.Sum(nil)), nil
+	})
+}
+
+// ExifIDGuid takes the first valid unique id from given tags, e.g. ImageUniqueID or DocumentID
+func ExifIDGuid(tags ...string) GuidStrategy {
+	return GuidFunc(func(item RawItemR) (string, error) {
+		for _, tag := range tags {
+			v, err := ExifString(item, tag)
+			if err != nil || strings.Trim(v, "0") == "" {
+				continue
+			}
+			return "id:" + strings.ToLower(v), nil
+		}
+		return "", fmt.Errorf("%w: none of %s", ErrExifMissing, strings.Join(tags, ", "))
+	})
+}
+
+// ImageDataHashGuid takes ImageDataHash tag computed by exiftool over image data only,
+// exiftool should be called with "-ImageDataHash" and optionally "-api ImageHashType=SHA256"
+func ImageDataHashGuid() GuidStrategy {
+	return GuidFunc(func(item RawItemR) (string, error) {
+		v, err := ExifString(item, "ImageDataHash")
+		if err != nil {
+			return "", err
+		}
+		return "hash:" + strings.ToLower(v), nil
+	})
+}
+
+// ContentGuid hashes file contents of RawContentProvider items excluding metadata.
+// Metadata segments of JPEG and chunks of PNG are skipped. Other formats, e.g. HEIC or TIFF based raws,
+// keep metadata next to image data, so they fail with ErrUnsupportedFormat, see ImageDataHashGuid
+func ContentGuid() GuidStrategy {
+	return GuidFunc(func(item RawItemR) (string, error) {
+		content, ok := ContentOf(item)
+		if !ok {
+			return "", errors.New("item doesn't provide file contents")
+		}
+		defer content.Close()
+
+		h := sha256.New()
+		if err := hashImageData(h, io.NewSectionReader(content, 0, content.Size())); err != nil {
+			return "", err
+		}
+		return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+	})
+}
+
+var (
+	jpegSOI = []byte{0xFF, 0xD8}
+	pngSig  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+func hashImageData(h hash.Hash, r *io.SectionReader) error {
+	head := make([]byte, 8)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, jpegSOI):
+		return hashJPEG(h, r)
+	case bytes.HasPrefix(head, pngSig):
+		return hashPNG(h, r)
+	}
+	return ErrUnsupportedFormat
+}
+
+// hashJPEG hashes every segment except APPn and comments up to EOI, so data appended after the image,
+// e.g. Motion Photo video, doesn't change the hash. Truncated files without EOI fail
+func hashJPEG(h hash.Hash, r *io.SectionReader) error {
+	var off int64 = 2
+	buf := make([]byte, 4)
+
+	for {
+		if _, err := r.ReadAt(buf[:2], off); err != nil {
+			return fmt.Errorf("jpeg: %w", err)
+		}
+		if buf[0] != 0xFF {
+			return errors.New("jpeg: marker expected")
+		}
+		marker := buf[1]
+		switch {
+		case marker == 0xFF:
+			// fill byte
+			off++
+			continue
+		case marker == 0xD9:
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			h.Write(buf[:2])
+			off += 2
+			continue
+		}
+
+		if _, err := r.ReadAt(buf[2:4], off+2); err != nil {
+			return fmt.Errorf("jpeg: %w", err)
+		}
+		length := int64(binary.BigEndian.Uint16(buf[2:4]))
+		if length < 2 {
+			return errors.New("jpeg: malformed segment length")
+		}
+
+		isMetadata := (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE
+		if !isMetadata {
+			if _, err := io.Copy(h, io.NewSectionReader(r, off, length+2)); err != nil {
+				return err
+			}
+		}
+		off += length + 2
+		if marker == 0xDA {
+			// start of scan is followed by entropy coded data
+			n, err := hashScan(h, r, off)
+			if err != nil {
+				return err
+			}
+			off += n
+		}
+	}
+}
+
+// hashScan hashes entropy coded data starting at off up to the next marker and returns its length.
+// Stuffed 0xFF00 bytes and restart markers are a part of the data
+func hashScan(h hash.Hash, r *io.SectionReader, off int64) (int64, error) {
+	buf := make([]byte, 32*1024)
+	start := off
+	for {
+		n, err := r.ReadAt(buf, off)
+		if n < 2 {
+			if err == nil || err == io.EOF {
+				err = errors.New("missing end of image")
+			}
+			return 0, fmt.Errorf("jpeg: %w", err)
+		}
+
+		// the last byte is scanned again with the next chunk, marker could be split between chunks
+		chunk := buf[:n]
+		for i := 0; i < n-1; {
+			j := bytes.IndexByte(chunk[i:n-1], 0xFF)
+			if j < 0 {
+				break
+			}
+			i += j
+			if b := chunk[i+1]; b != 0 && (b < 0xD0 || b > 0xD7) {
+				h.Write(chunk[:i])
+				return off + int64(i) - start, nil
+			}
+			i += 2
+		}
+		h.Write(chunk[:n-1])
+		off += int64(n - 1)
+	}
+}
+
+var pngMetadataChunks = map[string]bool{
+	"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true,
+}
+
+// hashPNG hashes types and data of all chunks except textual metadata, exif and timestamps
+func hashPNG(h hash.Hash, r *io.SectionReader) error {
+	off := int64(len(pngSig))
+	buf := make([]byte, 8)
+
+	for off < r.Size() {
+		if _, err := r.ReadAt(buf, off); err != nil {
+			return fmt.Errorf("png: %w", err)
+		}
+		length := int64(binary.BigEndian.Uint32(buf[:4]))
+		typ := string(buf[4:8])
+
+		if !pngMetadataChunks[typ] {
+			h.Write(buf[4:8])
+			if _, err := io.Copy(h, io.NewSectionReader(r, off+8, length)); err != nil {
+				return err
+			}
+		}
+		if typ == "IEND" {
+			return nil
+		}
+		off += length + 12
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 16), uint8(y * 16), 128, 255})
+		}
+	}
+	return img
+}
+
+// withJPEGSegment inserts APPn or COM segment right after SOI
+func withJPEGSegment(data []byte, marker byte, payload string) []byte {
+	seg := []byte{0xFF, marker, 0, byte(len(payload) + 2)}
+	seg = append(seg, payload...)
+	res := append([]byte{}, data[:2]...)
+	res = append(res, seg...)
+	return append(res, data[2:]...)
+}
+
+// withPNGText inserts tEXt chunk right after IHDR, crc is not validated by hashing
+func withPNGText(data []byte, text string) []byte {
+	ihdrEnd := 8 + 8 + 13 + 4
+	chunk := []byte{0, 0, 0, byte(len(text)), 't', 'E', 'X', 't'}
+	chunk = append(chunk, text...)
+	chunk = append(chunk, 0, 0, 0, 0)
+	res := append([]byte{}, data[:ihdrEnd]...)
+	res = append(res, chunk...)
+	return append(res, data[ihdrEnd:]...)
+}
+
+func contentItem(t *testing.T, name string, data []byte) RawItemR {
+	path := writeTempFile(t, name, data)
+	return NewFilePool(0).Wrap(NewRawItem(path, nil), path)
+}
+
+func TestContentGuid(t *testing.T) {
+	s := ContentGuid()
+
+	t.Run("jpeg metadata is ignored", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := s.Guid(contentItem(t, "a.jpg", buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := s.Guid(contentItem(t, "b.jpg", withJPEGSegment(buf.Bytes(), 0xE1, "Exif\x00\x00edited")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := s.Guid(contentItem(t, "c.jpg", withJPEGSegment(buf.Bytes(), 0xFE, "comment")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if a != b || a != c {
+			t.Errorf("guid should not depend on metadata: %s, %s, %s", a, b, c)
+		}
+		if !strings.HasPrefix(a, "sha256:") {
+			t.Errorf("unexpected guid %s", a)
+		}
+
+		var other bytes.Buffer
+		jpeg.Encode(&other, testImage(), &jpeg.Options{Quality: 50})
+		d, _ := s.Guid(contentItem(t, "d.jpg", other.Bytes()))
+		if a == d {
+			t.Error("different image data should give different guid")
+		}
+	})
+
+	t.Run("jpeg trailer is ignored", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := s.Guid(contentItem(t, "a.jpg", buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Motion Photo keeps video after EOI
+		trailer := append(bytes.Clone(buf.Bytes()), "\x00\x00\x00\x18ftypmp42\xff\xd9video"...)
+		b, err := s.Guid(contentItem(t, "b.jpg", trailer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != b {
+			t.Errorf("guid should not depend on data after the image: %s, %s", a, b)
+		}
+
+		truncated := buf.Bytes()[:buf.Len()-2]
+		if _, err := s.Guid(contentItem(t, "c.jpg", truncated)); err == nil {
+			t.Error("truncated jpeg should fail")
+		}
+	})
+
+	t.Run("png metadata is ignored", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, testImage()); err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := s.Guid(contentItem(t, "a.png", buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := s.Guid(contentItem(t, "b.png", withPNGText(buf.Bytes(), "Title\x00Sea")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != b {
+			t.Errorf("guid should not depend on metadata: %s, %s", a, b)
+		}
+	})
+
+	t.Run("other formats", func(t *testing.T) {
+		tiff := []byte("II*\x00\x08\x00\x00\x00")
+		if _, err := s.Guid(contentItem(t, "a.cr2", tiff)); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		if _, err := s.Guid(NewRawItem("a.jpg", nil)); err == nil {
+			t.Error("item without content should fail")
+		}
+	})
+}
+
+func TestGuidStrategies(t *testing.T) {
+	item := NewRawItem("a.jpg", exifOf(
+		"ImageUniqueID", "00000000000000000000000000000000",
+		"DocumentID", "xmp.did:ABC123",
+	))
+
+	guid, err := ExifIDGuid("ImageUniqueID", "DocumentID").Guid(item)
+	if err != nil || guid != "id:xmp.did:abc123" {
+		t.Errorf("zero ImageUniqueID should be skipped, got %s, %v", guid, err)
+	}
+
+	guid, err = DefaultGuidStrategy.Guid(item)
+	if err != nil || guid != "id:xmp.did:abc123" {
+		t.Errorf("unexpected default guid %s, %v", guid, err)
+	}
+
+	if _, err := DefaultGuidStrategy.Guid(NewRawItem("b.jpg", nil)); !errors.Is(err, ErrNoGuid) {
+		t.Errorf("expected ErrNoGuid, got %v", err)
+	}
+
+	combined := CombinedGuid(ExifIDGuid("DocumentID"), ImageDataHashGuid())
+	if _, err := combined.Guid(item); !errors.Is(err, ErrExifMissing) {
+		t.Errorf("combined guid should fail if any part is missing, got %v", err)
+	}
+
+	item = NewRawItem("c.jpg", exifOf("DocumentID", "A", "ImageDataHash", "FF"))
+	a, err := combined.Guid(item)
+	if err != nil || !strings.HasPrefix(a, "mix:") {
+		t.Errorf("unexpected combined guid %s, %v", a, err)
+	}
+
+	if err := AssignGuid(item, combined, "item_guid"); err != nil {
+		t.Fatal(err)
+	}
+	if item.GetGuid() != a || item.SetBy(FieldGuid) != "item_guid" {
+		t.Error("guid should be assigned on behalf of perceptor")
+	}
+}
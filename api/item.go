@@ -0,0 +1,372 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dukobpa3/perceplib/exiftool"
+)
+
+var ErrNotAnItem = errors.New("exiftool output is not an item")
+
+var itemHeader = []byte("======== ")
+
+// Field is a name of item data which could be set by perceptors
+type Field string
+
+const (
+	FieldGuid       Field = "guid"
+	FieldDate       Field = "date"
+	FieldSize       Field = "size"
+	FieldRatio      Field = "ratio"
+	FieldLocation   Field = "geo"
+	FieldMetadata   Field = "metadata"
+	FieldCompanions Field = "companions"
+)
+
+// ItemEditor is able to change every part of item data
+type ItemEditor interface {
+	ItemDataEditor
+	MetadataDataEditor
+	GuidEditor
+	CompanionEditor
+}
+
+// RawItem is a standard item built from exiftool output.
+// Implements RawItemR, ItemDataEditor and MetadataItemR,
+// remembers which perceptor changed each field last.
+// RawItem is safe for concurrent use by multiple goroutines.
+type RawItem struct {
+	mu          sync.RWMutex
+	path        string
+	exif        ExifSources
+	guid        string
+	date        time.Time
+	size        Size
+	ratio       Size
+	location    Location
+	hasLocation bool
+	metadata    Metadata
+	companions  []Companion
+	setBy       map[Field]string
+	results     *Results
+}
+
+var (
+	_ RawItemR             = (*RawItem)(nil)
+	_ SidecarExifProvider  = (*RawItem)(nil)
+	_ MetadataItemR        = (*RawItem)(nil)
+	_ ItemEditor           = (*RawItem)(nil)
+	_ OrientedDataProvider = (*RawItem)(nil)
+	_ ResultProvider       = (*RawItem)(nil)
+	_ CompanionProvider    = (*RawItem)(nil)
+	_ VideoProvider        = (*RawItem)(nil)
+)
+
+// NewRawItem creates item for the file with already parsed exif
+func NewRawItem(path string, exif RawExif) *RawItem {
+	if exif == nil {
+		exif = make(RawExif)
+	}
+	return &RawItem{
+		path:    path,
+		exif:    *NewExifSources(exif, nil, SidecarOverMain),
+		setBy:   make(map[Field]string),
+		results: NewResults(),
+	}
+}
+
+// ParseRawItem creates item from single object of exiftool output,
+// as it comes from exiftool.DefaultSplitter: "======== path" header followed by "Tag : value" lines.
+// Tags printed with groups are kept qualified and found by unqualified names too, see NewExifSources
+func ParseRawItem(data []byte) (*RawItem, error) {
+	data = bytes.TrimLeft(data, "\r\n")
+	if !bytes.HasPrefix(data, itemHeader) {
+		return nil, ErrNotAnItem
+	}
+
+	header, body, _ := bytes.Cut(data[len(itemHeader):], []byte("\n"))
+	path := string(bytes.TrimSpace(header))
+
+	body = bytes.TrimRight(body, "\r\n ")
+	exif := make(RawExif)
+	if len(body) > 0 {
+		// body is a part of caller's buffer, appending to it could overwrite the next record
+		if err := exiftool.Unmarshal(slices.Concat(body, []byte{'\n'}), exif); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewRawItem(path, exif), nil
+}
+
+func (i *RawItem) GetPath() string {
+	return i.path
+}
+
+// SetSidecar attaches exif read from sidecars and policy used by GetExif
+func (i *RawItem) SetSidecar(exif RawExif, policy SidecarPolicy) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.exif = *NewExifSources(i.exif.Main, exif, policy)
+}
+
+func (i *RawItem) GetExif(key string) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.exif.GetExif(key)
+}
+
+func (i *RawItem) GetExifFrom(key string, policy SidecarPolicy) (string, ExifSource) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.exif.GetExifFrom(key, policy)
+}
+
+// GetRawExif returns copy of main file exif
+func (i *RawItem) GetRawExif() RawExif {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return maps.Clone(i.exif.Main)
+}
+
+// GetMergedExif returns copy of main file exif merged with sidecar exif according to sidecar policy
+func (i *RawItem) GetMergedExif() RawExif {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.exif.Merged()
+}
+
+func (i *RawItem) GetGuid() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.guid
+}
+
+func (i *RawItem) GetDate() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.date
+}
+
+func (i *RawItem) GetSize() Size {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.size
+}
+
+func (i *RawItem) GetRatio() Size {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.ratio
+}
+
+func (i *RawItem) GetOrientation() Orientation {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return ExifOrientation(&i.exif)
+}
+
+func (i *RawItem) GetDisplaySize() Size {
+	return OrientedSize(i.GetSize(), i.GetOrientation())
+}
+
+func (i *RawItem) GetDisplayRatio() Size {
+	return OrientedSize(i.GetRatio(), i.GetOrientation())
+}
+
+func (i *RawItem) GetLocation() (Location, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.location, i.hasLocation
+}
+
+func (i *RawItem) GetMetadata() Metadata {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return Metadata{
+		Tags:       slices.Clone(i.metadata.Tags),
+		Categories: slices.Clone(i.metadata.Categories),
+		Event:      i.metadata.Event,
+	}
+}
+
+// GetVideo describes video from exif, false for still images
+func (i *RawItem) GetVideo() (VideoInfo, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	v, err := ExifVideo(&i.exif)
+	return v, err == nil
+}
+
+func (i *RawItem) GetCompanions() []Companion {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return slices.Clone(i.companions)
+}
+
+// GetResults returns results of perceptors, Results has its own synchronization
+func (i *RawItem) GetResults() *Results {
+	return i.results
+}
+
+// SetBy returns name of perceptor which changed the field last,
+// empty if field wasn't set by any perceptor. Changes without author, e.g. RawItem.SetDate, keep it
+func (i *RawItem) SetBy(field Field) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.setBy[field]
+}
+
+// EditorFor returns editor which records given perceptor as author of changes
+func (i *RawItem) EditorFor(perceptor string) ItemEditor {
+	return &itemEditor{item: i, perceptor: perceptor}
+}
+
+type editorProvider interface {
+	EditorFor(perceptor string) ItemEditor
+}
+
+type unwrapper interface {
+	Unwrap() RawItemR
+}
+
+// EditorOf returns editor of the item, or of the item it wraps, on behalf of given perceptor.
+// Perceptor is recorded as author of changes if item supports it, see RawItem.EditorFor
+func EditorOf(item RawItemR, perceptor string) (ItemDataEditor, bool) {
+	for item != nil {
+		if e, ok := item.(editorProvider); ok {
+			return e.EditorFor(perceptor), true
+		}
+		if e, ok := item.(ItemDataEditor); ok {
+			return e, true
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil, false
+}
+
+type pathProvider interface {
+	GetPath() string
+}
+
+// PathOf returns path of the item, or of the item it wraps,
+// items without path are looked up by SourceFile tag of their exif
+func PathOf(item RawItemR) (string, bool) {
+	for it := item; it != nil; {
+		if p, ok := it.(pathProvider); ok {
+			if path := p.GetPath(); path != "" {
+				return path, true
+			}
+		}
+		u, ok := it.(unwrapper)
+		if !ok {
+			break
+		}
+		it = u.Unwrap()
+	}
+	if item == nil {
+		return "", false
+	}
+	path := item.GetExif("SourceFile")
+	return path, path != ""
+}
+
+type mergedExifProvider interface {
+	GetMergedExif() RawExif
+}
+
+// RawExifOf returns copy of exif of the item, or of the item it wraps, as GetExif sees it,
+// see RawItem.GetMergedExif
+func RawExifOf(item RawItemR) (RawExif, bool) {
+	for item != nil {
+		if p, ok := item.(mergedExifProvider); ok {
+			return p.GetMergedExif(), true
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil, false
+}
+
+func (i *RawItem) SetGuid(guid string)                { i.EditorFor("").SetGuid(guid) }
+func (i *RawItem) SetDate(date time.Time)             { i.EditorFor("").SetDate(date) }
+func (i *RawItem) SetSize(size Size)                  { i.EditorFor("").SetSize(size) }
+func (i *RawItem) SetRatio(ratio Size)                { i.EditorFor("").SetRatio(ratio) }
+func (i *RawItem) SetLocation(location Location)      { i.EditorFor("").SetLocation(location) }
+func (i *RawItem) AddTags(tags ...string)             { i.EditorFor("").AddTags(tags...) }
+func (i *RawItem) AddCategories(categories ...string) { i.EditorFor("").AddCategories(categories...) }
+func (i *RawItem) SetEvent(event string)              { i.EditorFor("").SetEvent(event) }
+func (i *RawItem) AddCompanion(c Companion)           { i.EditorFor("").AddCompanion(c) }
+
+type itemEditor struct {
+	item      *RawItem
+	perceptor string
+}
+
+func (e *itemEditor) edit(field Field, fn func(i *RawItem)) {
+	e.item.mu.Lock()
+	defer e.item.mu.Unlock()
+	fn(e.item)
+	if e.perceptor != "" {
+		e.item.setBy[field] = e.perceptor
+	}
+}
+
+func (e *itemEditor) SetGuid(guid string) {
+	e.edit(FieldGuid, func(i *RawItem) { i.guid = guid })
+}
+
+func (e *itemEditor) SetDate(date time.Time) {
+	e.edit(FieldDate, func(i *RawItem) { i.date = date })
+}
+
+func (e *itemEditor) SetSize(size Size) {
+	e.edit(FieldSize, func(i *RawItem) { i.size = size })
+}
+
+func (e *itemEditor) SetRatio(ratio Size) {
+	e.edit(FieldRatio, func(i *RawItem) { i.ratio = ratio })
+}
+
+func (e *itemEditor) SetLocation(location Location) {
+	e.edit(FieldLocation, func(i *RawItem) {
+		i.location = location
+		i.hasLocation = true
+	})
+}
+
+func (e *itemEditor) AddTags(tags ...string) {
+	e.edit(FieldMetadata, func(i *RawItem) {
+		for _, tag := range tags {
+			i.metadata.Tags = AppendUniq(i.metadata.Tags, tag)
+		}
+	})
+}
+
+func (e *itemEditor) AddCategories(categories ...string) {
+	e.edit(FieldMetadata, func(i *RawItem) {
+		for _, c := range categories {
+			i.metadata.Categories = AppendUniq(i.metadata.Categories, c)
+		}
+	})
+}
+
+func (e *itemEditor) SetEvent(event string) {
+	e.edit(FieldMetadata, func(i *RawItem) { i.metadata.Event = event })
+}
+
+func (e *itemEditor) AddCompanion(c Companion) {
+	e.edit(FieldCompanions, func(i *RawItem) { i.companions = addCompanion(i.companions, c) })
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRawItem(t *testing.T) {
+	t.Run("object", func(t *testing.T) {
+		item, err := ParseRawItem([]byte("======== ./_MG_5112.JPG\nMIMEType                        : image/jpeg\nDateTimeOriginal                : 2024:06:01 10:20:30\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.GetPath() != "./_MG_5112.JPG" {
+			t.Errorf("unexpected path %q", item.GetPath())
+		}
+		if v := item.GetExif("DateTimeOriginal"); v != "2024:06:01 10:20:30" {
+			t.Errorf("unexpected DateTimeOriginal %q", v)
+		}
+		if v := item.GetExif("MIMEType"); v != "image/jpeg" {
+			t.Errorf("unexpected MIMEType %q", v)
+		}
+	})
+
+	t.Run("without trailing newline", func(t *testing.T) {
+		item, err := ParseRawItem([]byte("======== ./a.jpg\nMake : Canon"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.GetExif("Make") != "Canon" {
+			t.Error("last line should be parsed")
+		}
+	})
+
+	t.Run("buffer of caller", func(t *testing.T) {
+		buf := []byte("======== ./a.jpg\nMake : Canon======== ./b.jpg")
+		record := buf[:len("======== ./a.jpg\nMake : Canon")]
+		if _, err := ParseRawItem(record); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[len(record):]) != "======== ./b.jpg" {
+			t.Errorf("next record is overwritten: %q", buf[len(record):])
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		_, err := ParseRawItem([]byte("    1 image files read"))
+		if !errors.Is(err, ErrNotAnItem) {
+			t.Errorf("expected ErrNotAnItem, got %v", err)
+		}
+	})
+}
+
+func TestRawItemSetBy(t *testing.T) {
+	item := NewRawItem("a.jpg", nil)
+	date := time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC)
+
+	item.EditorFor("exif_date").SetDate(date)
+	item.EditorFor("exif_size").SetSize(Size{W: 6000, H: 4000})
+
+	if !item.GetDate().Equal(date) {
+		t.Errorf("unexpected date %v", item.GetDate())
+	}
+	if item.SetBy(FieldDate) != "exif_date" || item.SetBy(FieldSize) != "exif_size" {
+		t.Error("unexpected authors of fields")
+	}
+	if item.SetBy(FieldRatio) != "" {
+		t.Error("ratio wasn't set")
+	}
+
+	item.SetDate(date.Add(time.Hour))
+	if item.SetBy(FieldDate) != "exif_date" {
+		t.Error("change without author should keep previous one")
+	}
+}
+
+func TestRawItemConcurrentEdit(t *testing.T) {
+	item := NewRawItem("a.jpg", RawExif{"Make": []byte("Canon")})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			editor := item.EditorFor("p")
+			editor.SetSize(Size{W: i, H: i})
+			editor.AddTags("tag")
+			_ = item.GetSize()
+			_ = item.GetExif("Make")
+		}(i)
+	}
+	wg.Wait()
+
+	if tags := item.GetMetadata().Tags; len(tags) != 1 {
+		t.Errorf("expected single tag, got %v", tags)
+	}
+}
+
+func TestRawItemCompanions(t *testing.T) {
+	item := NewRawItem("a.cr2", nil)
+	jpg := NewRawItem("a.jpg", nil)
+
+	item.EditorFor("pair").AddCompanion(Companion{Role: CompanionJPEG, Path: "a.jpg"})
+	item.EditorFor("pair").AddCompanion(Companion{Role: CompanionJPEG, Path: "a.jpg", Item: jpg})
+	item.AddCompanion(Companion{Role: CompanionMotionPhoto, Path: "a.cr2", Embedded: true})
+
+	companions := CompanionsOf(Enrich(item))
+	if len(companions) != 2 {
+		t.Fatalf("expected 2 companions, got %+v", companions)
+	}
+	if companions[0].Item != jpg {
+		t.Error("companion with the same role and path should be replaced")
+	}
+	if item.SetBy(FieldCompanions) != "pair" {
+		t.Errorf("unexpected author %q", item.SetBy(FieldCompanions))
+	}
+}
+
+func TestPathOf(t *testing.T) {
+	if path, ok := PathOf(Enrich(NewRawItem("a.jpg", nil))); !ok || path != "a.jpg" {
+		t.Errorf("path of wrapped item should be found, got %q", path)
+	}
+	if path, ok := PathOf(NewRawItem("", RawExif{"SourceFile": []byte("b.jpg")})); !ok || path != "b.jpg" {
+		t.Errorf("path should be taken from exif, got %q", path)
+	}
+	if _, ok := PathOf(&mockItem{}); ok {
+		t.Error("item without path shouldn't have one")
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"slices"
+	"sync"
+)
+
+// Enrich returns item which provides MetadataDataProvider and MetadataDataEditor.
+// Items which already implement both are returned as is, others are wrapped with in-memory storage.
+// Other interfaces of the wrapped item are reachable with EditorOf, ContentOf and Unwrap
+func Enrich(item RawItemR) MetadataItemR {
+	if mi, ok := item.(MetadataItemR); ok {
+		if _, ok := item.(MetadataDataEditor); ok {
+			return mi
+		}
+	}
+	return &metadataItem{RawItemR: item}
+}
+
+// MetadataOf returns location and metadata provider of the item, or of the item it wraps
+func MetadataOf(item RawItemR) (MetadataDataProvider, bool) {
+	for item != nil {
+		if mp, ok := item.(MetadataDataProvider); ok {
+			return mp, true
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil, false
+}
+
+// EnrichDecorator is a chain.Decorator which converts RawItemR to MetadataItemR with Enrich
+type EnrichDecorator struct{}
+
+func (EnrichDecorator) Decorate(item RawItemR) (MetadataItemR, error) {
+	return Enrich(item), nil
+}
+
+func (EnrichDecorator) Stop() {}
+
+type metadataItem struct {
+	RawItemR
+	mu          sync.RWMutex
+	location    Location
+	hasLocation bool
+	metadata    Metadata
+}
+
+func (m *metadataItem) Unwrap() RawItemR {
+	return m.RawItemR
+}
+
+func (m *metadataItem) GetPath() string {
+	path, _ := PathOf(m.RawItemR)
+	return path
+}
+
+func (m *metadataItem) GetLocation() (Location, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.location, m.hasLocation
+}
+
+func (m *metadataItem) GetMetadata() Metadata {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return Metadata{
+		Tags:       slices.Clone(m.metadata.Tags),
+		Categories: slices.Clone(m.metadata.Categories),
+		Event:      m.metadata.Event,
+	}
+}
+
+func (m *metadataItem) SetLocation(location Location) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.location = location
+	m.hasLocation = true
+}
+
+func (m *metadataItem) AddTags(tags ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tag := range tags {
+		m.metadata.Tags = AppendUniq(m.metadata.Tags, tag)
+	}
+}
+
+func (m *metadataItem) AddCategories(categories ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range categories {
+		m.metadata.Categories = AppendUniq(m.metadata.Categories, c)
+	}
+}
+
+func (m *metadataItem) SetEvent(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadata.Event = event
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+type mockItem struct {
+	exif map[string]string
+}
+
+func (m *mockItem) GetGuid() string           { return "" }
+func (m *mockItem) GetDate() time.Time        { return time.Time{} }
+func (m *mockItem) GetSize() Size             { return Size{} }
+func (m *mockItem) GetRatio() Size            { return Size{} }
+func (m *mockItem) GetExif(key string) string { return m.exif[key] }
+
+func TestEnrich(t *testing.T) {
+	item := Enrich(&mockItem{exif: map[string]string{"Make": "Canon"}})
+
+	if item.GetExif("Make") != "Canon" {
+		t.Error("enriched item should keep exif of original one")
+	}
+
+	if _, ok := item.GetLocation(); ok {
+		t.Error("location should not be set")
+	}
+
+	editor, ok := item.(MetadataDataEditor)
+	if !ok {
+		t.Fatal("enriched item should implement MetadataDataEditor")
+	}
+
+	editor.SetLocation(Location{Latitude: 50.45, Longitude: 30.52})
+	editor.AddTags("sea", "sunset", "sea")
+	editor.AddCategories("travel")
+	editor.SetEvent("Summer 2024")
+
+	if loc, ok := item.GetLocation(); !ok || loc.Latitude != 50.45 {
+		t.Errorf("unexpected location %v", loc)
+	}
+
+	meta := item.GetMetadata()
+	if len(meta.Tags) != 2 {
+		t.Errorf("tags should be unique, got %v", meta.Tags)
+	}
+	if len(meta.Categories) != 1 || meta.Event != "Summer 2024" {
+		t.Errorf("unexpected metadata %v", meta)
+	}
+
+	if Enrich(item) != item {
+		t.Error("already enriched item should not be wrapped again")
+	}
+}
+
+func TestEnrichKeepsWrappedInterfaces(t *testing.T) {
+	path := writeTempFile(t, "a.jpg", []byte("0123456789"))
+	item := Enrich(NewFilePool(0).Wrap(&mockItem{}, path))
+
+	if pp, ok := item.(interface{ GetPath() string }); !ok || pp.GetPath() != path {
+		t.Error("enriched item should provide path")
+	}
+
+	content, ok := ContentOf(item)
+	if !ok {
+		t.Fatal("contents of wrapped item should be reachable")
+	}
+	defer content.Close()
+	if content.Size() != 10 {
+		t.Errorf("expected size 10, got %d", content.Size())
+	}
+
+	raw := NewRawItem(path, nil)
+	editor, ok := EditorOf(Enrich(NewFilePool(0).Wrap(raw, path)), "exif_size")
+	if !ok {
+		t.Fatal("editor of wrapped raw item should be reachable")
+	}
+	editor.SetSize(Size{W: 4, H: 3})
+	if raw.GetSize() != (Size{W: 4, H: 3}) || raw.SetBy(FieldSize) != "exif_size" {
+		t.Error("size should be set on wrapped raw item")
+	}
+}
+
+func TestMetadataOf(t *testing.T) {
+	item := NewRawItem("a.jpg", nil)
+	item.SetLocation(Location{Latitude: 1, Longitude: 2})
+	item.AddTags("sea")
+
+	mp, ok := MetadataOf(NewFilePool(0).Wrap(item, "a.jpg"))
+	if !ok {
+		t.Fatal("metadata of wrapped item should be found")
+	}
+	if loc, ok := mp.GetLocation(); !ok || loc.Latitude != 1 || mp.GetMetadata().Tags[0] != "sea" {
+		t.Errorf("unexpected location %v and metadata %v", loc, mp.GetMetadata())
+	}
+
+	if _, ok := MetadataOf(&mockItem{}); ok {
+		t.Error("item without metadata should not be found")
+	}
+}
@@ -36,3 +36,24 @@ type ExifPerceptor interface {
 	Perceptor
 	NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor
 }
+
+// RawPerceptor is a specialized interface for processors working with file contents
+type RawPerceptor interface {
+	Perceptor
+	NewProcessor(chin <-chan RawDataItemR, chout chan<- RawDataItemR, logger *l.Logger) chain.Processor
+}
+
+// MetadataPerceptor is a specialized interface for processors working with results of other perceptors.
+// Items are passed through Enrich before, so processors could rely on MetadataDataEditor as well
+type MetadataPerceptor interface {
+	Perceptor
+	NewProcessor(chin <-chan MetadataItemR, chout chan<- MetadataItemR, logger *l.Logger) chain.Processor
+}
+
+// GroupPerceptor is a specialized interface for processors working in ItemGroup mode.
+// Processor receives items one by one, collects them and emits resulting groups,
+// see chain.NewGroupCollector
+type GroupPerceptor interface {
+	Perceptor
+	NewGroupProcessor(chin <-chan RawItemR, chout chan<- ItemGroupR, logger *l.Logger) chain.Processor
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dukobpa3/perceplib/chain"
+	"github.com/dukobpa3/perceplib/exiftool"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+// DefaultExifArgs are common arguments of exiftool server used by Pipeline,
+// short tag names are expected by ParseRawItem and all exif accessors.
+// Groups tell apart QuickTime and Matroska dates of videos, see ExifVideoDate.
+// Duplicate tags are not requested with -a, exiftool prints the preferred one of them.
+// ImageDataHash is computed only on request, so all other tags are requested explicitly, see ImageDataHashGuid
+var DefaultExifArgs = []string{"-s", "-G", "-all", "-ImageDataHash"}
+
+// DefaultMaxOpenFiles limits files opened by perceptors of Pipeline without own FilePool
+const DefaultMaxOpenFiles = 32
+
+// FileSource provides paths of files to process
+type FileSource interface {
+	Files(ctx context.Context) ([]string, error)
+}
+
+// FileList is a FileSource with fixed list of paths
+type FileList []string
+
+func (f FileList) Files(context.Context) ([]string, error) {
+	return f, nil
+}
+
+// Sink receives items which passed all perceptors
+type Sink interface {
+	Consume(item RawItemR) error
+}
+
+// SinkFunc is a Sink as a plain function
+type SinkFunc func(item RawItemR) error
+
+func (f SinkFunc) Consume(item RawItemR) error {
+	return f(item)
+}
+
+// Pipeline reads exif of all source files with single exiftool server,
+// passes items through perceptors and gives them to the sink.
+// Perceptors are ordered by their dependencies and run as stages of a pipeline,
+// raw, metadata and group perceptors run through adapters, see AsExifPerceptor
+type Pipeline struct {
+	Source     FileSource
+	Sink       Sink
+	Perceptors []Perceptor
+	Logger     *l.Logger
+
+	Config        ConfigSet     // settings of configurable perceptors, applied to their clones before start if not nil
+	ExifArgs      []string      // common arguments of exiftool server, DefaultExifArgs if nil
+	Files         *FilePool     // gives items contents, see RawContentProvider, NewFilePool(DefaultMaxOpenFiles) if nil
+	Sidecars      bool          // read xmp sidecars, see FindSidecars
+	SidecarPolicy SidecarPolicy // policy of items with sidecars
+}
+
+func NewPipeline(source FileSource, sink Sink, logger *l.Logger, perceptors ...Perceptor) *Pipeline {
+	return &Pipeline{
+		Source:     source,
+		Sink:       sink,
+		Perceptors: perceptors,
+		Logger:     logger,
+	}
+}
+
+func (p *Pipeline) exifArgs() []string {
+	if p.ExifArgs == nil {
+		return DefaultExifArgs
+	}
+	return p.ExifArgs
+}
+
+// Run processes all files of the source and returns when every item has left the pipeline.
+// Errors of single items don't stop the pipeline, they are joined into returned error.
+// Perceptors of unsupported kinds fail the run before any file is read
+func (p *Pipeline) Run(ctx context.Context) error {
+	configured := p.Perceptors
+	if p.Config != nil {
+		var err error
+		if configured, err = Configured(configured, p.Config); err != nil {
+			return err
+		}
+	}
+
+	perceptors := make([]ExifPerceptor, len(configured))
+	for i, c := range configured {
+		var err error
+		if perceptors[i], err = AsExifPerceptor(c); err != nil {
+			return fmt.Errorf("pipeline: %w", err)
+		}
+	}
+
+	files, err := p.Source.Files(ctx)
+	if err != nil {
+		return err
+	}
+
+	errch := make(chan error)
+	ch := chain.NewChainProcessor(errch)
+
+	// every step closes its output when its input is drained,
+	// so the sink returns once the entry point has sent all files and the rest of the chain is done
+	entry := &pipelineEntry{files: files, policy: p.SidecarPolicy, pool: p.Files}
+	if entry.pool == nil {
+		entry.pool = NewFilePool(DefaultMaxOpenFiles)
+	}
+	items := make(chan RawItemR)
+	ch.AddStep(chain.WithClose(chain.NewEntryPoint(items, entry), func() { close(items) }))
+
+	perceived := make(chan RawItemR)
+	if err := BuildChain(ch, perceptors, items, perceived, p.Logger); err != nil {
+		return err
+	}
+	ch.AddStep(chain.NewSink(perceived, &pipelineSink{sink: p.Sink}))
+
+	// exiftool writes to its own channel, so it never blocks on cancelled chain
+	entry.out = make(chan string)
+	if entry.server, err = exiftool.NewServerCh(entry.out, exiftool.DefaultSplitter, p.exifArgs()...); err != nil {
+		return fmt.Errorf("pipeline: %w", err)
+	}
+	defer entry.server.Close()
+
+	if p.Sidecars {
+		if entry.sidecarServer, err = exiftool.NewServer(p.exifArgs()...); err != nil {
+			return fmt.Errorf("pipeline: %w", err)
+		}
+		defer entry.sidecarServer.Close()
+	}
+
+	var errs []error
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for err := range errch {
+			if !errors.Is(err, chain.ErrSkippedItem) {
+				errs = append(errs, err)
+			}
+		}
+	}()
+
+	ch.Process(ctx)
+
+	close(errch)
+	<-collected
+
+	errs = append(errs, entry.err())
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+type pipelineEntry struct {
+	files         []string
+	server        *exiftool.Server
+	out           chan string
+	sidecarServer *exiftool.Server
+	policy        SidecarPolicy
+	pool          *FilePool
+
+	mu    sync.Mutex
+	fatal error // exiftool failure, reported once the pipeline is done
+}
+
+func (e *pipelineEntry) err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.fatal
+}
+
+// Start sends exiftool output of all files and closes ch, so the entry point returns when they are handled
+func (e *pipelineEntry) Start(ch chan<- string, ctx context.Context) {
+	defer close(ch)
+	if len(e.files) == 0 {
+		return
+	}
+
+	go func() {
+		defer close(e.out)
+		if err := e.server.CommandCh(e.files...); err != nil {
+			e.mu.Lock()
+			e.fatal = fmt.Errorf("pipeline: %w", err)
+			e.mu.Unlock()
+		}
+	}()
+
+	for token := range e.out {
+		select {
+		case ch <- token:
+		case <-ctx.Done():
+			for range e.out {
+			}
+			return
+		}
+	}
+}
+
+func (e *pipelineEntry) Decorate(token string) (RawItemR, error) {
+	if strings.HasPrefix(token, "err exiftool") {
+		return nil, errors.New(strings.TrimPrefix(token, "err "))
+	}
+
+	raw, err := ParseRawItem([]byte(token))
+	if errors.Is(err, ErrNotAnItem) {
+		// exiftool reports like "    1 image files read"
+		return nil, chain.ErrSkippedItem
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if e.sidecarServer != nil {
+		sidecar, _, err := ReadSidecars(e.sidecarServer, raw.GetPath())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", raw.GetPath(), err)
+		}
+		if sidecar != nil {
+			raw.SetSidecar(sidecar, e.policy)
+		}
+	}
+
+	return e.pool.Wrap(raw, raw.GetPath()), nil
+}
+
+func (e *pipelineEntry) Stop() {
+	e.server.Close()
+}
+
+type pipelineSink struct {
+	sink Sink
+}
+
+func (s *pipelineSink) Consume(item RawItemR) error {
+	return s.sink.Consume(item)
+}
+
+func (s *pipelineSink) Stop() {}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/chain"
+	"github.com/dukobpa3/perceplib/exiftool"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+// fakeExiftool emulates stay_open protocol of exiftool, every file gets Make and FileName tags,
+// files with "missing" in name are reported as not found
+const fakeExiftool = `#!/bin/sh
+files=""
+while IFS= read -r line; do
+	case "$line" in
+	-execute*)
+		n=0
+		for f in $files; do
+			case "$f" in
+			*missing*) echo "Error: File not found - $f" >&2 ;;
+			*)
+				printf '======== %s\nMake                            : Test\nFileName                        : %s\n' "$f" "$(basename "$f")"
+				n=$((n+1)) ;;
+			esac
+		done
+		printf '    %d image files read\n' $n
+		echo "{ready1854673209}"
+		echo "{ready1854673209}" >&2
+		files="" ;;
+	false) exit 0 ;;
+	-*) ;;
+	*) files="$files $line" ;;
+	esac
+done
+`
+
+func withFakeExiftool(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake exiftool is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "exiftool")
+	if err := os.WriteFile(path, []byte(fakeExiftool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	exec, arg1, config := exiftool.Exec, exiftool.Arg1, exiftool.Config
+	exiftool.Exec, exiftool.Arg1, exiftool.Config = path, "", ""
+	t.Cleanup(func() {
+		exiftool.Exec, exiftool.Arg1, exiftool.Config = exec, arg1, config
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	withFakeExiftool(t)
+
+	var mu sync.Mutex
+	var sunk []string
+	sink := SinkFunc(func(item RawItemR) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sunk = append(sunk, item.GetExif("FileName"))
+		content, ok := ContentOf(item)
+		if !ok {
+			return errors.New("item doesn't provide contents")
+		}
+		return content.Close()
+	})
+
+	errBroken := errors.New("broken")
+	size := dependent("size", nil, []Field{FieldSize})
+	size.process = func(item RawItemR) {
+		editor, _ := EditorOf(item, "size")
+		editor.SetSize(Size{W: 3, H: 2})
+	}
+	check := dependent("check", []Field{FieldSize}, nil)
+	check.fail = func(item RawItemR) error {
+		if item.GetSize() != (Size{W: 3, H: 2}) {
+			return errors.New("size is not set")
+		}
+		if strings.Contains(item.GetExif("FileName"), "broken") {
+			return errBroken
+		}
+		return nil
+	}
+
+	files := FileList{"/photos/a.jpg", "/photos/broken.jpg", "/photos/missing.jpg", "/photos/b.jpg"}
+	p := NewPipeline(files, sink, nil, check, size)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := p.Run(ctx)
+	if !errors.Is(err, errBroken) {
+		t.Errorf("expected error of broken item, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "File not found") {
+		t.Errorf("expected error of missing file, got %v", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Error("pipeline didn't finish before deadline")
+	}
+
+	slices.Sort(sunk)
+	if !slices.Equal(sunk, []string{"a.jpg", "b.jpg"}) {
+		t.Errorf("unexpected sunk items %v", sunk)
+	}
+}
+
+// dropper loses items with "lost" in file name without reporting any error
+type dropper struct {
+	mockPerceptor
+}
+
+func (d *dropper) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewSwitch(chin, []chan<- RawItemR{chout}, d)
+}
+
+func (d *dropper) Switch(item RawItemR) (map[int]RawItemR, error) {
+	if strings.Contains(item.GetExif("FileName"), "lost") {
+		return nil, nil
+	}
+	return map[int]RawItemR{0: item}, nil
+}
+
+func (d *dropper) Stop() {}
+
+func TestPipelineDroppedItems(t *testing.T) {
+	withFakeExiftool(t)
+
+	var mu sync.Mutex
+	var sunk []string
+	sink := SinkFunc(func(item RawItemR) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sunk = append(sunk, item.GetExif("FileName"))
+		return nil
+	})
+
+	// size and drop run in parallel in the first stage, check waits for both of them
+	size := dependent("size", nil, []Field{FieldSize})
+	size.process = func(item RawItemR) {
+		editor, _ := EditorOf(item, "size")
+		editor.SetSize(Size{W: 3, H: 2})
+	}
+	check := dependent("check", []Field{FieldSize}, nil)
+	check.fail = func(item RawItemR) error {
+		if item.GetSize() != (Size{W: 3, H: 2}) {
+			return errors.New("size is not set")
+		}
+		return nil
+	}
+	drop := &dropper{mockPerceptor{name: "drop"}}
+
+	files := FileList{"/photos/a.jpg", "/photos/lost.jpg", "/photos/b.jpg"}
+	p := NewPipeline(files, sink, nil, check, size, drop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.Run(ctx); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	slices.Sort(sunk)
+	if !slices.Equal(sunk, []string{"a.jpg", "b.jpg"}) {
+		t.Errorf("unexpected sunk items %v", sunk)
+	}
+}
+
+func TestPipelineEmptySource(t *testing.T) {
+	withFakeExiftool(t)
+
+	p := NewPipeline(FileList{}, SinkFunc(func(RawItemR) error { return nil }), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.Run(ctx); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// reader checks contents of raw items
+type reader struct {
+	mockPerceptor
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *reader) NewProcessor(chin <-chan RawDataItemR, chout chan<- RawDataItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, decorateFunc[RawDataItemR, RawDataItemR](func(item RawDataItemR) (RawDataItemR, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		path, _ := PathOf(item)
+		r.paths = append(r.paths, path)
+		return item, item.GetContent().Close()
+	}))
+}
+
+// tagger tags metadata items with their file name
+type tagger struct {
+	mockPerceptor
+}
+
+func (t *tagger) Provides() []Field { return []Field{FieldMetadata} }
+func (t *tagger) Requires() []Field { return nil }
+
+func (t *tagger) NewProcessor(chin <-chan MetadataItemR, chout chan<- MetadataItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, decorateFunc[MetadataItemR, MetadataItemR](func(item MetadataItemR) (MetadataItemR, error) {
+		editor, ok := item.(MetadataDataEditor)
+		if !ok {
+			return nil, errors.New("metadata item can't be changed")
+		}
+		editor.AddTags(item.GetExif("FileName"))
+		return item, nil
+	}))
+}
+
+// grouper puts all items into single group
+type grouper struct {
+	mockPerceptor
+	size int
+}
+
+func (g *grouper) NewGroupProcessor(chin <-chan RawItemR, chout chan<- ItemGroupR, logger *l.Logger) chain.Processor {
+	return chain.NewGroupCollector(chin, chout, g)
+}
+
+func (g *grouper) Group(items []RawItemR) ([]ItemGroupR, error) {
+	g.size = len(items)
+	return []ItemGroupR{&Group{ID: "all", Items: items}, &Group{ID: "first", Items: items[:1]}}, nil
+}
+
+func (g *grouper) Stop() {}
+
+func TestPipelineAdapters(t *testing.T) {
+	withFakeExiftool(t)
+
+	var mu sync.Mutex
+	var sunk []string
+	sink := SinkFunc(func(item RawItemR) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sunk = append(sunk, item.GetExif("FileName"))
+		if _, ok := item.(RawDataItemR); !ok {
+			return errors.New("item which came out of adapters is not the pipeline item")
+		}
+		md, _ := MetadataOf(item)
+		if tags := md.GetMetadata().Tags; !slices.Equal(tags, []string{item.GetExif("FileName")}) {
+			return errors.New("unexpected tags " + strings.Join(tags, ","))
+		}
+		return nil
+	})
+
+	// tagger and grouper run in parallel, check waits for the tags
+	raw := &reader{mockPerceptor: mockPerceptor{name: "raw", provider: RawDataProvider}}
+	tags := &tagger{mockPerceptor{name: "tags", provider: MetadataProvider}}
+	group := &grouper{mockPerceptor: mockPerceptor{name: "group", mode: ItemGroup}}
+	check := dependent("check", []Field{FieldMetadata}, nil)
+	check.fail = func(item RawItemR) error {
+		if md, _ := MetadataOf(item); len(md.GetMetadata().Tags) == 0 {
+			return errors.New("tags are not set")
+		}
+		return nil
+	}
+
+	files := FileList{"/photos/a.jpg", "/photos/b.jpg", "/photos/c.jpg"}
+	p := NewPipeline(files, sink, nil, check, raw, tags, group)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.Run(ctx); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	slices.Sort(sunk)
+	if !slices.Equal(sunk, []string{"a.jpg", "b.jpg", "c.jpg"}) {
+		t.Errorf("unexpected sunk items %v", sunk)
+	}
+	slices.Sort(raw.paths)
+	if !slices.Equal(raw.paths, files) {
+		t.Errorf("raw perceptor got %v", raw.paths)
+	}
+	if group.size != len(files) {
+		t.Errorf("group perceptor got %d items", group.size)
+	}
+}
+
+func TestPipelineUnsupportedPerceptor(t *testing.T) {
+	withFakeExiftool(t)
+
+	sink := SinkFunc(func(RawItemR) error { return nil })
+	for _, p := range []Perceptor{
+		&mockPerceptor{name: "plain"},
+		&dropper{mockPerceptor{name: "drop", mode: ItemGroup}},
+	} {
+		err := NewPipeline(FileList{"/photos/a.jpg"}, sink, nil, p).Run(context.Background())
+		if !errors.Is(err, ErrUnsupportedPerceptor) {
+			t.Errorf("%s: expected ErrUnsupportedPerceptor, got %v", p.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrZeroSize        = errors.New("size has zero dimension")
+	ErrNoStandardRatio = errors.New("no standard ratio within tolerance")
+)
+
+// StandardRatios are common photo and video formats in landscape orientation
+var StandardRatios = []Size{
+	{1, 1}, {5, 4}, {4, 3}, {7, 5}, {3, 2}, {16, 10}, {5, 3},
+	{16, 9}, {2, 1}, {21, 9}, {65, 24}, {3, 1},
+}
+
+// DefaultRatioTolerance allows 1% deviation from standard ratio
+const DefaultRatioTolerance = 0.01
+
+// RatioClassifier snaps sizes to the nearest ratio from configured set
+type RatioClassifier struct {
+	Ratios    []Size  // standard ratios, orientation doesn't matter
+	Tolerance float64 // max relative deviation, e.g. 0.01 for 1%
+}
+
+func NewRatioClassifier() *RatioClassifier {
+	return &RatioClassifier{
+		Ratios:    StandardRatios,
+		Tolerance: DefaultRatioTolerance,
+	}
+}
+
+// longSide returns ratio of longer side to shorter one
+func longSide(s Size) float64 {
+	w, h := float64(s.W), float64(s.H)
+	if h > w {
+		w, h = h, w
+	}
+	return w / h
+}
+
+// Classify returns the nearest standard ratio in orientation of given size and its relative deviation.
+// If nearest ratio is out of tolerance it is still returned together with ErrNoStandardRatio
+func (c *RatioClassifier) Classify(size Size) (Size, float64, error) {
+	if size.W <= 0 || size.H <= 0 {
+		return Size{}, 0, ErrZeroSize
+	}
+	if len(c.Ratios) == 0 {
+		return GetRatio(size), 0, ErrNoStandardRatio
+	}
+
+	actual := longSide(size)
+	best := Size{}
+	deviation := math.Inf(1)
+	for _, r := range c.Ratios {
+		if r.W <= 0 || r.H <= 0 {
+			continue
+		}
+		d := math.Abs(actual/longSide(r) - 1)
+		if d < deviation {
+			best, deviation = r, d
+		}
+	}
+
+	if best.W < best.H {
+		best.W, best.H = best.H, best.W
+	}
+	if size.H > size.W {
+		best.W, best.H = best.H, best.W
+	}
+
+	if deviation > c.Tolerance {
+		return best, deviation, ErrNoStandardRatio
+	}
+	return best, deviation, nil
+}
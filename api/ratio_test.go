@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetRatio(t *testing.T) {
+	if r := GetRatio(Size{W: 6000, H: 4000}); r != (Size{W: 3, H: 2}) {
+		t.Errorf("expected 3:2, got %v", r)
+	}
+	if r := GetRatio(Size{}); r != (Size{}) {
+		t.Errorf("expected zero ratio, got %v", r)
+	}
+}
+
+func TestRatioClassifier(t *testing.T) {
+	c := NewRatioClassifier()
+
+	tests := []struct {
+		name     string
+		size     Size
+		expected Size
+		err      error
+	}{
+		{"cropped 3:2", Size{W: 6000, H: 3997}, Size{W: 3, H: 2}, nil},
+		{"portrait 4:3", Size{W: 3024, H: 4032}, Size{W: 3, H: 4}, nil},
+		{"square", Size{W: 1080, H: 1080}, Size{W: 1, H: 1}, nil},
+		{"full hd", Size{W: 1920, H: 1080}, Size{W: 16, H: 9}, nil},
+		{"xpan", Size{W: 6500, H: 2400}, Size{W: 65, H: 24}, nil},
+		{"panorama", Size{W: 12000, H: 2000}, Size{W: 3, H: 1}, ErrNoStandardRatio},
+		{"zero", Size{W: 0, H: 1000}, Size{}, ErrZeroSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := c.Classify(tt.size)
+			if !errors.Is(err, tt.err) || got != tt.expected {
+				t.Errorf("expected %v (%v), got %v (%v)", tt.expected, tt.err, got, err)
+			}
+		})
+	}
+
+	t.Run("custom tolerance", func(t *testing.T) {
+		strict := &RatioClassifier{Ratios: []Size{{3, 2}}, Tolerance: 0.0001}
+		got, deviation, err := strict.Classify(Size{W: 6000, H: 3997})
+		if !errors.Is(err, ErrNoStandardRatio) || got != (Size{W: 3, H: 2}) || deviation <= 0 {
+			t.Errorf("unexpected %v, %v, %v", got, deviation, err)
+		}
+	})
+}
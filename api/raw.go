@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// FilePool limits amount of simultaneously opened files for RawContent handles.
+// FilePool is safe for concurrent use by multiple goroutines.
+type FilePool struct {
+	slots chan struct{}
+}
+
+// NewFilePool creates pool which allows maxOpen files opened at the same time.
+// maxOpen <= 0 means no limit
+func NewFilePool(maxOpen int) *FilePool {
+	p := &FilePool{}
+	if maxOpen > 0 {
+		p.slots = make(chan struct{}, maxOpen)
+	}
+	return p
+}
+
+// acquire waits for free slot, false if done is closed first
+func (p *FilePool) acquire(done <-chan struct{}) bool {
+	if p.slots == nil {
+		return true
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+func (p *FilePool) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+// Open returns lazy handle for the file with given path
+func (p *FilePool) Open(path string) RawContent {
+	return &rawFile{pool: p, path: path, size: -1, done: make(chan struct{})}
+}
+
+// Wrap attaches file contents from given path to the item
+func (p *FilePool) Wrap(item RawItemR, path string) RawDataItemR {
+	return &rawDataItem{RawItemR: item, pool: p, path: path}
+}
+
+// ContentOf opens contents of the item, or of the item it wraps
+func ContentOf(item RawItemR) (RawContent, bool) {
+	for item != nil {
+		if cp, ok := item.(RawContentProvider); ok {
+			return cp.GetContent(), true
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil, false
+}
+
+type rawDataItem struct {
+	RawItemR
+	pool *FilePool
+	path string
+}
+
+func (r *rawDataItem) Unwrap() RawItemR {
+	return r.RawItemR
+}
+
+// GetPath returns path of the attached file, embedded interface doesn't promote it
+func (r *rawDataItem) GetPath() string {
+	return r.path
+}
+
+func (r *rawDataItem) GetContent() RawContent {
+	return r.pool.Open(r.path)
+}
+
+type rawFile struct {
+	pool   *FilePool
+	path   string
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	err    error
+	closed bool
+	done   chan struct{} // closed by Close, interrupts waiting for a slot
+}
+
+func (r *rawFile) open() (*os.File, error) {
+	r.mu.Lock()
+	f, err, closed := r.file, r.err, r.closed
+	r.mu.Unlock()
+
+	if closed {
+		return nil, os.ErrClosed
+	}
+	if f != nil || err != nil {
+		return f, err
+	}
+
+	// slot is taken without holding the lock, so Close and Size are not blocked by full pool
+	if !r.pool.acquire(r.done) {
+		return nil, os.ErrClosed
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		r.pool.release()
+		return nil, os.ErrClosed
+	}
+	if r.file != nil || r.err != nil {
+		// opened concurrently
+		r.pool.release()
+		return r.file, r.err
+	}
+
+	f, err = os.Open(r.path)
+	if err != nil {
+		r.pool.release()
+		r.err = err
+		return nil, err
+	}
+
+	r.file = f
+	return f, nil
+}
+
+func (r *rawFile) ReadAt(b []byte, off int64) (int, error) {
+	f, err := r.open()
+	if err != nil {
+		return 0, err
+	}
+	return f.ReadAt(b, off)
+}
+
+// Size returns file size or 0 if file is not accessible.
+// Doesn't occupy slot in the pool if file wasn't opened yet
+func (r *rawFile) Size() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size >= 0 {
+		return r.size
+	}
+
+	var info os.FileInfo
+	var err error
+	if r.file != nil {
+		info, err = r.file.Stat()
+	} else {
+		info, err = os.Stat(r.path)
+	}
+	if err != nil {
+		return 0
+	}
+
+	r.size = info.Size()
+	return r.size
+}
+
+func (r *rawFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return errors.New("raw content already closed")
+	}
+	r.closed = true
+	close(r.done)
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	r.pool.release()
+	return err
+}
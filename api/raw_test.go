@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTempFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFilePool(t *testing.T) {
+	t.Run("read content", func(t *testing.T) {
+		path := writeTempFile(t, "a.jpg", []byte("0123456789"))
+		pool := NewFilePool(1)
+
+		c := pool.Open(path)
+		defer c.Close()
+
+		if c.Size() != 10 {
+			t.Errorf("expected size 10, got %d", c.Size())
+		}
+
+		buf := make([]byte, 3)
+		if _, err := c.ReadAt(buf, 4); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "456" {
+			t.Errorf("expected 456, got %s", buf)
+		}
+	})
+
+	t.Run("lazy opening", func(t *testing.T) {
+		pool := NewFilePool(1)
+		c := pool.Open(filepath.Join(t.TempDir(), "missing.jpg"))
+
+		// nothing is opened until first read
+		if len(pool.slots) != 0 {
+			t.Error("file should not be opened before read")
+		}
+
+		_, err := c.ReadAt(make([]byte, 1), 0)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected not exist error, got %v", err)
+		}
+		if len(pool.slots) != 0 {
+			t.Error("failed open should not occupy slot")
+		}
+		c.Close()
+	})
+
+	t.Run("bounded open files", func(t *testing.T) {
+		path := writeTempFile(t, "a.jpg", []byte("0123456789"))
+		pool := NewFilePool(1)
+
+		first := pool.Open(path)
+		if _, err := first.ReadAt(make([]byte, 1), 0); err != nil {
+			t.Fatal(err)
+		}
+
+		read := make(chan struct{})
+		go func() {
+			second := pool.Open(path)
+			defer second.Close()
+			second.ReadAt(make([]byte, 1), 0)
+			close(read)
+		}()
+
+		select {
+		case <-read:
+			t.Fatal("second file opened while pool is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		first.Close()
+
+		select {
+		case <-read:
+		case <-time.After(time.Second):
+			t.Fatal("second file not opened after slot released")
+		}
+	})
+
+	t.Run("close while waiting for slot", func(t *testing.T) {
+		path := writeTempFile(t, "a.jpg", []byte("0123456789"))
+		pool := NewFilePool(1)
+
+		first := pool.Open(path)
+		defer first.Close()
+		if _, err := first.ReadAt(make([]byte, 1), 0); err != nil {
+			t.Fatal(err)
+		}
+
+		second := pool.Open(path)
+		read := make(chan error)
+		go func() {
+			_, err := second.ReadAt(make([]byte, 1), 0)
+			read <- err
+		}()
+
+		// size and close of the waiting handle should not block behind the full pool
+		time.Sleep(20 * time.Millisecond)
+		if second.Size() != 10 {
+			t.Errorf("expected size 10, got %d", second.Size())
+		}
+		second.Close()
+
+		select {
+		case err := <-read:
+			if !errors.Is(err, os.ErrClosed) {
+				t.Errorf("expected os.ErrClosed, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("read is not interrupted by close")
+		}
+		if len(pool.slots) != 1 {
+			t.Error("closed handle should not occupy slot")
+		}
+	})
+
+	t.Run("closed content", func(t *testing.T) {
+		path := writeTempFile(t, "a.jpg", []byte("0123456789"))
+		c := NewFilePool(0).Open(path)
+		c.Close()
+
+		if _, err := c.ReadAt(make([]byte, 1), 0); !errors.Is(err, os.ErrClosed) {
+			t.Errorf("expected os.ErrClosed, got %v", err)
+		}
+		if err := c.Close(); err == nil {
+			t.Error("repeated close should fail")
+		}
+	})
+}
+
+func TestWrapPath(t *testing.T) {
+	item := NewFilePool(0).Wrap(NewRawItem("a.jpg", nil), "a.jpg")
+	if pp, ok := item.(interface{ GetPath() string }); !ok || pp.GetPath() != "a.jpg" {
+		t.Error("wrapped item should provide path")
+	}
+}
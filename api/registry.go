@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrDuplicatePerceptor = errors.New("perceptor already registered")
+	ErrUnknownPerceptor   = errors.New("unknown perceptor")
+)
+
+// Filter decides whether perceptor should be included into the listing
+type Filter func(Perceptor) bool
+
+// ByDataProvider keeps perceptors working with given data provider
+func ByDataProvider(provider DataProviderType) Filter {
+	return func(p Perceptor) bool {
+		return p.DataProvider() == provider
+	}
+}
+
+// ByProcessingMode keeps perceptors working in given processing mode
+func ByProcessingMode(mode ProcessingMode) Filter {
+	return func(p Perceptor) bool {
+		return p.ProcessingMode() == mode
+	}
+}
+
+// Registry keeps known perceptors by their unique names.
+// Registry is safe for concurrent use by multiple goroutines.
+type Registry struct {
+	mu         sync.RWMutex
+	perceptors map[string]Perceptor
+	names      []string // registration order
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		perceptors: make(map[string]Perceptor),
+	}
+}
+
+// Register adds perceptor to the registry, perceptor names must be unique
+func (r *Registry) Register(p Perceptor) error {
+	if p == nil {
+		return errors.New("perceptor is nil")
+	}
+
+	name := p.Name()
+	if name == "" {
+		return errors.New("perceptor name is empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.perceptors[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicatePerceptor, name)
+	}
+
+	r.perceptors[name] = p
+	r.names = append(r.names, name)
+	return nil
+}
+
+// Get returns perceptor registered with given name
+func (r *Registry) Get(name string) (Perceptor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.perceptors[name]
+	return p, ok
+}
+
+// List returns perceptors in registration order which pass all given filters
+func (r *Registry) List(filters ...Filter) []Perceptor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var res []Perceptor
+	for _, name := range r.names {
+		p := r.perceptors[name]
+		if matchAll(p, filters) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Select returns perceptors with given names in the same order, e.g. taken from configuration.
+// Fails if any of names is not registered.
+func (r *Registry) Select(names ...string) ([]Perceptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]Perceptor, 0, len(names))
+	var errs []error
+	for _, name := range names {
+		p, ok := r.perceptors[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownPerceptor, name))
+			continue
+		}
+		res = append(res, p)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return res, nil
+}
+
+func matchAll(p Perceptor, filters []Filter) bool {
+	for _, f := range filters {
+		if f != nil && !f(p) {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by package level Register
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds perceptor to the default registry.
+// Intended to be called from init functions, so it panics on duplicated or invalid perceptor.
+func Register(p Perceptor) {
+	if err := defaultRegistry.Register(p); err != nil {
+		panic("api: Register " + err.Error())
+	}
+}
+
+// Perceptors lists perceptors from the default registry which pass all given filters
+func Perceptors(filters ...Filter) []Perceptor {
+	return defaultRegistry.List(filters...)
+}
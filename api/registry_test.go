@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockPerceptor struct {
+	name     string
+	provider DataProviderType
+	mode     ProcessingMode
+}
+
+func (m *mockPerceptor) Name() string                   { return m.name }
+func (m *mockPerceptor) DataProvider() DataProviderType { return m.provider }
+func (m *mockPerceptor) ProcessingMode() ProcessingMode { return m.mode }
+
+func TestRegistry(t *testing.T) {
+	t.Run("duplicate name", func(t *testing.T) {
+		r := NewRegistry()
+		if err := r.Register(&mockPerceptor{name: "exif_date"}); err != nil {
+			t.Fatal(err)
+		}
+
+		err := r.Register(&mockPerceptor{name: "exif_date", provider: RawDataProvider})
+		if !errors.Is(err, ErrDuplicatePerceptor) {
+			t.Errorf("expected ErrDuplicatePerceptor, got %v", err)
+		}
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		r := NewRegistry()
+		if err := r.Register(&mockPerceptor{}); err == nil {
+			t.Error("perceptor with empty name should be rejected")
+		}
+	})
+
+	t.Run("list with filters", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register(&mockPerceptor{name: "exif_date"})
+		r.Register(&mockPerceptor{name: "ml_color", provider: RawDataProvider})
+		r.Register(&mockPerceptor{name: "burst", mode: ItemGroup})
+		r.Register(&mockPerceptor{name: "exif_size"})
+
+		if got := r.List(); len(got) != 4 {
+			t.Errorf("expected 4 perceptors, got %d", len(got))
+		}
+
+		got := r.List(ByDataProvider(ExifDataProvider), ByProcessingMode(SingleItem))
+		expected := []string{"exif_date", "exif_size"}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d perceptors, got %d", len(expected), len(got))
+		}
+		for i, name := range expected {
+			if got[i].Name() != name {
+				t.Errorf("perceptor %d: expected %s, got %s", i, name, got[i].Name())
+			}
+		}
+	})
+
+	t.Run("select by names", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register(&mockPerceptor{name: "exif_date"})
+		r.Register(&mockPerceptor{name: "exif_size"})
+
+		got, err := r.Select("exif_size", "exif_date")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].Name() != "exif_size" || got[1].Name() != "exif_date" {
+			t.Error("selected perceptors should keep requested order")
+		}
+
+		_, err = r.Select("exif_date", "exif_geo")
+		if !errors.Is(err, ErrUnknownPerceptor) {
+			t.Errorf("expected ErrUnknownPerceptor, got %v", err)
+		}
+	})
+}
+
+func TestRegisterPanicsOnDuplicate(t *testing.T) {
+	// keep default registry clean for other tests
+	saved := defaultRegistry
+	defaultRegistry = NewRegistry()
+	t.Cleanup(func() { defaultRegistry = saved })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register should panic on duplicated name")
+		}
+	}()
+
+	p := &mockPerceptor{name: "exif_date"}
+	Register(p)
+	Register(p)
+}
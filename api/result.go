@@ -0,0 +1,355 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Versioned is an optional Perceptor extension, version should change whenever results could change
+type Versioned interface {
+	Version() string
+}
+
+// PerceptorVersion returns version of versioned perceptor or empty string
+func PerceptorVersion(p Perceptor) string {
+	if v, ok := p.(Versioned); ok {
+		return v.Version()
+	}
+	return ""
+}
+
+// ValueKind is a type of result value
+type ValueKind string
+
+const (
+	KindString  ValueKind = "string"
+	KindInt     ValueKind = "int"
+	KindFloat   ValueKind = "float"
+	KindBool    ValueKind = "bool"
+	KindTime    ValueKind = "time"
+	KindStrings ValueKind = "strings"
+	KindBytes   ValueKind = "bytes"
+	KindJSON    ValueKind = "json"
+)
+
+// Value is an immutable typed result value which keeps its type through JSON
+type Value struct {
+	kind ValueKind
+	v    any
+}
+
+func StringValue(s string) Value          { return Value{KindString, s} }
+func IntValue(i int64) Value              { return Value{KindInt, i} }
+func FloatValue(f float64) Value          { return Value{KindFloat, f} }
+func BoolValue(b bool) Value              { return Value{KindBool, b} }
+func TimeValue(t time.Time) Value         { return Value{KindTime, t} } // JSON keeps offset, not location name
+func StringsValue(s []string) Value       { return Value{KindStrings, slices.Clone(s)} }
+func BytesValue(b []byte) Value           { return Value{KindBytes, bytes.Clone(b)} }
+func JSONValue(raw json.RawMessage) Value { return Value{KindJSON, json.RawMessage(bytes.Clone(raw))} }
+
+func (v Value) Kind() ValueKind {
+	return v.kind
+}
+
+func (v Value) AsString() (string, bool) {
+	s, ok := v.v.(string)
+	return s, ok && v.kind == KindString
+}
+
+func (v Value) AsInt() (int64, bool) {
+	i, ok := v.v.(int64)
+	return i, ok && v.kind == KindInt
+}
+
+// AsFloat returns float value, int values are converted
+func (v Value) AsFloat() (float64, bool) {
+	switch x := v.v.(type) {
+	case float64:
+		return x, v.kind == KindFloat
+	case int64:
+		return float64(x), v.kind == KindInt
+	}
+	return 0, false
+}
+
+func (v Value) AsBool() (bool, bool) {
+	b, ok := v.v.(bool)
+	return b, ok && v.kind == KindBool
+}
+
+// AsTime returns time value, values restored from JSON have fixed zone with original offset
+func (v Value) AsTime() (time.Time, bool) {
+	t, ok := v.v.(time.Time)
+	return t, ok && v.kind == KindTime
+}
+
+func (v Value) AsStrings() ([]string, bool) {
+	s, ok := v.v.([]string)
+	return slices.Clone(s), ok && v.kind == KindStrings
+}
+
+func (v Value) AsBytes() ([]byte, bool) {
+	b, ok := v.v.([]byte)
+	return bytes.Clone(b), ok && v.kind == KindBytes
+}
+
+func (v Value) AsJSON() (json.RawMessage, bool) {
+	raw, ok := v.v.(json.RawMessage)
+	return json.RawMessage(bytes.Clone(raw)), ok && v.kind == KindJSON
+}
+
+type valueJSON struct {
+	Kind  ValueKind       `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	var payload any = v.v
+	switch x := v.v.(type) {
+	case int64:
+		// keep integers exact for any consumer
+		payload = strconv.FormatInt(x, 10)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			payload = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+	case time.Time:
+		payload = x.Format(time.RFC3339Nano)
+	case nil:
+		return nil, errors.New("result value is not initialized")
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(valueJSON{Kind: v.kind, Value: raw})
+}
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var vj valueJSON
+	if err := json.Unmarshal(data, &vj); err != nil {
+		return err
+	}
+
+	var err error
+	switch vj.Kind {
+	case KindString:
+		var s string
+		err = json.Unmarshal(vj.Value, &s)
+		*v = StringValue(s)
+	case KindInt:
+		var s string
+		if err = json.Unmarshal(vj.Value, &s); err == nil {
+			var i int64
+			i, err = strconv.ParseInt(s, 10, 64)
+			*v = IntValue(i)
+		}
+	case KindFloat:
+		var f float64
+		if err = json.Unmarshal(vj.Value, &f); err != nil {
+			var s string
+			if json.Unmarshal(vj.Value, &s) == nil {
+				f, err = strconv.ParseFloat(s, 64)
+			}
+		}
+		*v = FloatValue(f)
+	case KindBool:
+		var b bool
+		err = json.Unmarshal(vj.Value, &b)
+		*v = BoolValue(b)
+	case KindTime:
+		var s string
+		if err = json.Unmarshal(vj.Value, &s); err == nil {
+			var t time.Time
+			t, err = time.Parse(time.RFC3339Nano, s)
+			*v = TimeValue(t)
+		}
+	case KindStrings:
+		var s []string
+		err = json.Unmarshal(vj.Value, &s)
+		*v = StringsValue(s)
+	case KindBytes:
+		var b []byte
+		err = json.Unmarshal(vj.Value, &b)
+		*v = BytesValue(b)
+	case KindJSON:
+		*v = JSONValue(vj.Value)
+	default:
+		err = fmt.Errorf("unknown result value kind %q", vj.Kind)
+	}
+	return err
+}
+
+// Result is an output of single perceptor for single item
+type Result struct {
+	Perceptor  string           `json:"perceptor"`
+	Version    string           `json:"version,omitempty"`
+	Confidence float64          `json:"confidence"`
+	Tags       []string         `json:"tags,omitempty"`
+	Values     map[string]Value `json:"values,omitempty"`
+}
+
+// NewResult creates empty result of given perceptor with full confidence
+func NewResult(p Perceptor) *Result {
+	return &Result{
+		Perceptor:  p.Name(),
+		Version:    PerceptorVersion(p),
+		Confidence: 1,
+	}
+}
+
+// Set stores value with given key, returns result itself to chain calls
+func (r *Result) Set(key string, v Value) *Result {
+	if r.Values == nil {
+		r.Values = make(map[string]Value)
+	}
+	r.Values[key] = v
+	return r
+}
+
+func (r *Result) Get(key string) (Value, bool) {
+	v, ok := r.Values[key]
+	return v, ok
+}
+
+// AddTags adds unique tags, returns result itself to chain calls
+func (r *Result) AddTags(tags ...string) *Result {
+	for _, tag := range tags {
+		r.Tags = AppendUniq(r.Tags, tag)
+	}
+	return r
+}
+
+func (r *Result) Clone() *Result {
+	c := *r
+	c.Tags = slices.Clone(r.Tags)
+	c.Values = maps.Clone(r.Values)
+	return &c
+}
+
+// Results keeps results of different perceptors keyed by perceptor name.
+// Results is safe for concurrent use by multiple goroutines.
+type Results struct {
+	mu     sync.RWMutex
+	byName map[string]*Result
+}
+
+func NewResults() *Results {
+	return &Results{byName: make(map[string]*Result)}
+}
+
+// Set stores copy of result replacing previous result of the same perceptor
+func (rs *Results) Set(r *Result) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.byName == nil {
+		rs.byName = make(map[string]*Result)
+	}
+	rs.byName[r.Perceptor] = r.Clone()
+}
+
+// Get returns copy of result of given perceptor
+func (rs *Results) Get(perceptor string) (*Result, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	r, ok := rs.byName[perceptor]
+	if !ok {
+		return nil, false
+	}
+	return r.Clone(), true
+}
+
+func (rs *Results) Delete(perceptor string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.byName, perceptor)
+}
+
+// All returns copies of all results ordered by perceptor name
+func (rs *Results) All() []*Result {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	res := make([]*Result, 0, len(rs.byName))
+	for _, name := range slices.Sorted(maps.Keys(rs.byName)) {
+		res = append(res, rs.byName[name].Clone())
+	}
+	return res
+}
+
+func (rs *Results) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rs.All())
+}
+
+func (rs *Results) UnmarshalJSON(data []byte) error {
+	var all []*Result
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	byName := make(map[string]*Result, len(all))
+	for i, r := range all {
+		if r == nil || r.Perceptor == "" {
+			return fmt.Errorf("result %d has no perceptor", i)
+		}
+		byName[r.Perceptor] = r
+	}
+	rs.byName = byName
+	return nil
+}
+
+// ResultProvider is implemented by items which keep perceptor results
+type ResultProvider interface {
+	GetResults() *Results
+}
+
+// ResultsOf returns results of the item, or of the item it wraps
+func ResultsOf(item RawItemR) (*Results, bool) {
+	for item != nil {
+		if rp, ok := item.(ResultProvider); ok {
+			return rp.GetResults(), true
+		}
+		u, ok := item.(unwrapper)
+		if !ok {
+			break
+		}
+		item = u.Unwrap()
+	}
+	return nil, false
+}
+
+// ErrNoResults is returned by SetResult for items without results storage.
+// Perceptors fail such items with this error, items of Pipeline always keep results
+var ErrNoResults = errors.New("item doesn't keep results")
+
+// CheckResults fails with ErrNoResults unless every item keeps results.
+// Perceptors check items before changing them, so failed items are left untouched
+func CheckResults(items ...RawItemR) error {
+	for _, item := range items {
+		if _, ok := ResultsOf(item); !ok {
+			return ErrNoResults
+		}
+	}
+	return nil
+}
+
+// SetResult stores result into the item, see ErrNoResults
+func SetResult(item RawItemR, r *Result) error {
+	rs, ok := ResultsOf(item)
+	if !ok {
+		return ErrNoResults
+	}
+	rs.Set(r)
+	return nil
+}
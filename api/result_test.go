@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type versionedPerceptor struct {
+	mockPerceptor
+}
+
+func (v *versionedPerceptor) Version() string { return "1.2.0" }
+
+func TestResultJSON(t *testing.T) {
+	date := time.Date(2024, 6, 1, 10, 20, 30, 123456789, time.FixedZone("", 3*3600))
+
+	r := NewResult(&versionedPerceptor{mockPerceptor{name: "exif_date"}})
+	r.Confidence = 0.85
+	r.AddTags("night", "flash", "night")
+	r.Set("date", TimeValue(date)).
+		Set("source", StringValue("DateTimeOriginal")).
+		Set("count", IntValue(math.MaxInt64)).
+		Set("ratio", FloatValue(1.0/3)).
+		Set("nan", FloatValue(math.NaN())).
+		Set("zone", BoolValue(true)).
+		Set("palette", StringsValue([]string{"red", "navy"})).
+		Set("hash", BytesValue([]byte{0, 1, 254, 255})).
+		Set("extra", JSONValue(json.RawMessage(`{"a":[1,2]}`)))
+
+	results := NewResults()
+	results.Set(r)
+	results.Set(&Result{Perceptor: "exif_size", Confidence: 1})
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := NewResults()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.All()) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(decoded.All()))
+	}
+
+	got, ok := decoded.Get("exif_date")
+	if !ok {
+		t.Fatal("exif_date result is lost")
+	}
+	if got.Version != "1.2.0" || got.Confidence != 0.85 || len(got.Tags) != 2 {
+		t.Errorf("unexpected result header %+v", got)
+	}
+
+	value := func(key string) Value {
+		v, ok := got.Get(key)
+		if !ok {
+			t.Fatalf("value %s is lost", key)
+		}
+		return v
+	}
+
+	v, ok := value("date").AsTime()
+	if !ok || !v.Equal(date) || v.Nanosecond() != date.Nanosecond() {
+		t.Errorf("unexpected date %v", v)
+	}
+	if _, offset := v.Zone(); offset != 3*3600 {
+		t.Errorf("offset is lost: %d", offset)
+	}
+	if v, ok := value("source").AsString(); !ok || v != "DateTimeOriginal" {
+		t.Errorf("unexpected source %v", v)
+	}
+	if v, ok := value("count").AsInt(); !ok || v != math.MaxInt64 {
+		t.Errorf("unexpected count %v", v)
+	}
+	if v, ok := value("ratio").AsFloat(); !ok || v != 1.0/3 {
+		t.Errorf("unexpected ratio %v", v)
+	}
+	if v, ok := value("nan").AsFloat(); !ok || !math.IsNaN(v) {
+		t.Errorf("unexpected nan %v", v)
+	}
+	if v, ok := value("zone").AsBool(); !ok || !v {
+		t.Errorf("unexpected zone %v", v)
+	}
+	if v, ok := value("palette").AsStrings(); !ok || len(v) != 2 || v[1] != "navy" {
+		t.Errorf("unexpected palette %v", v)
+	}
+	if v, ok := value("hash").AsBytes(); !ok || len(v) != 4 || v[3] != 255 {
+		t.Errorf("unexpected hash %v", v)
+	}
+	if v, ok := value("extra").AsJSON(); !ok || string(v) != `{"a":[1,2]}` {
+		t.Errorf("unexpected extra %s", v)
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("round trip changed json:\n%s\n%s", data, again)
+	}
+}
+
+func TestResultsMalformedJSON(t *testing.T) {
+	for _, data := range []string{`[null]`, `[{"confidence":1}]`} {
+		if err := json.Unmarshal([]byte(data), NewResults()); err == nil {
+			t.Errorf("%s: expected error", data)
+		}
+	}
+}
+
+func TestValueKind(t *testing.T) {
+	if _, ok := (Value{}).AsStrings(); ok {
+		t.Error("zero value should not be a list")
+	}
+	if _, ok := IntValue(1).AsBool(); ok {
+		t.Error("int value should not be a bool")
+	}
+	if v, ok := IntValue(2).AsFloat(); !ok || v != 2 {
+		t.Error("int value should be converted to float")
+	}
+}
+
+func TestResultsOfItem(t *testing.T) {
+	item := NewRawItem("a.jpg", nil)
+	wrapped := Enrich(item)
+
+	if err := SetResult(wrapped, &Result{Perceptor: "exif_date", Confidence: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := item.GetResults().Get("exif_date"); !ok {
+		t.Error("result should be stored in wrapped item")
+	}
+
+	if err := SetResult(&mockItem{}, &Result{Perceptor: "exif_date"}); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected ErrNoResults, got %v", err)
+	}
+	if err := CheckResults(item, &mockItem{}); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected ErrNoResults, got %v", err)
+	}
+}
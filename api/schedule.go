@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+var (
+	ErrDependencyCycle  = errors.New("perceptor dependency cycle")
+	ErrUnmetRequirement = errors.New("perceptor requirement is not provided")
+)
+
+// Dependent is an optional Perceptor extension which declares item fields perceptor reads and sets
+type Dependent interface {
+	Requires() []Field
+	Provides() []Field
+}
+
+func requires(p Perceptor) []Field {
+	if d, ok := p.(Dependent); ok {
+		return d.Requires()
+	}
+	return nil
+}
+
+func provides(p Perceptor) []Field {
+	if d, ok := p.(Dependent); ok {
+		return d.Provides()
+	}
+	return nil
+}
+
+// Schedule orders perceptors by their dependencies into stages.
+// Perceptors inside the stage don't depend on each other and could run in parallel,
+// every stage depends on previous ones only. Order of independent perceptors is kept.
+// available lists fields which are already set before the first stage
+func Schedule[P Perceptor](perceptors []P, available ...Field) ([][]P, error) {
+	providers := make(map[Field][]int)
+	for i, p := range perceptors {
+		for _, f := range provides(p) {
+			providers[f] = AppendUniq(providers[f], i)
+		}
+	}
+
+	var errs []error
+	dependents := make([][]int, len(perceptors))
+	indegree := make([]int, len(perceptors))
+
+	for i, p := range perceptors {
+		var deps []int
+		for _, f := range requires(p) {
+			prov := providers[f]
+			if len(prov) == 0 && !slices.Contains(available, f) {
+				errs = append(errs, fmt.Errorf("%w: %s requires %q", ErrUnmetRequirement, p.Name(), f))
+				continue
+			}
+			for _, j := range prov {
+				if j != i {
+					deps = AppendUniq(deps, j)
+				}
+			}
+		}
+		for _, j := range deps {
+			dependents[j] = append(dependents[j], i)
+		}
+		indegree[i] = len(deps)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var stages [][]P
+	done := 0
+	current := make([]int, 0)
+	for i := range perceptors {
+		if indegree[i] == 0 {
+			current = append(current, i)
+		}
+	}
+
+	for len(current) > 0 {
+		stage := make([]P, 0, len(current))
+		var next []int
+		for _, i := range current {
+			stage = append(stage, perceptors[i])
+			for _, j := range dependents[i] {
+				indegree[j]--
+				if indegree[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		done += len(current)
+		stages = append(stages, stage)
+		slices.Sort(next)
+		current = next
+	}
+
+	if done < len(perceptors) {
+		var names []string
+		for i, p := range perceptors {
+			if indegree[i] > 0 {
+				names = append(names, p.Name())
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(names, ", "))
+	}
+
+	return stages, nil
+}
+
+// broadcaster sends every item to all branches of parallel stage
+type broadcaster struct {
+	branches int
+}
+
+func (b *broadcaster) Switch(item RawItemR) (map[int]RawItemR, error) {
+	res := make(map[int]RawItemR, b.branches)
+	for i := 0; i < b.branches; i++ {
+		res[i] = item
+	}
+	return res, nil
+}
+
+func (b *broadcaster) Stop() {}
+
+// BuildChain schedules perceptors by their dependencies and adds them to the chain.
+// Stages follow each other, perceptors of the same stage run in parallel on the same item
+// and item goes further only after all of them are done, stages with group perceptors wait for all items.
+// Every step closes its output when it returns, so chout is closed once chin is closed and drained.
+// Items passed between perceptors should be comparable (e.g. pointers like *RawItem)
+func BuildChain(ch chain.ChainProcessor, perceptors []ExifPerceptor, chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger, available ...Field) error {
+	stages, err := Schedule(perceptors, available...)
+	if err != nil {
+		return err
+	}
+
+	if len(stages) == 0 {
+		ch.AddStep(chain.WithClose(chain.NewDecorator(chin, chout, passThrough{}), func() { close(chout) }))
+		return nil
+	}
+
+	current := chin
+	for i, stage := range stages {
+		var next chan<- RawItemR
+		var nextR <-chan RawItemR
+		if i == len(stages)-1 {
+			next = chout
+		} else {
+			c := make(chan RawItemR)
+			next, nextR = c, c
+		}
+		closeNext := func() { close(next) }
+
+		if len(stage) == 1 {
+			ch.AddStep(chain.WithClose(stage[0].NewProcessor(current, next, logger), closeNext))
+			current = nextR
+			continue
+		}
+
+		branches := make([]chan<- RawItemR, len(stage))
+		results := make([]<-chan RawItemR, len(stage))
+		for j, p := range stage {
+			in := make(chan RawItemR)
+			out := make(chan RawItemR)
+			branches[j], results[j] = in, out
+			ch.AddStep(chain.WithClose(p.NewProcessor(in, out, logger), func() { close(out) }))
+		}
+		closeBranches := func() {
+			for _, b := range branches {
+				close(b)
+			}
+		}
+		ch.AddStep(chain.WithClose(chain.NewSwitch(current, branches, &broadcaster{branches: len(stage)}), closeBranches))
+		ch.AddStep(chain.WithClose(chain.NewJoinLimit(results, next, joinLimit(stage)), closeNext))
+		current = nextR
+	}
+
+	return nil
+}
+
+// joinLimit lets join of the stage wait for every item if any perceptor holds them until all are grouped
+func joinLimit(stage []ExifPerceptor) int {
+	for _, p := range stage {
+		if p.ProcessingMode() == ItemGroup {
+			return math.MaxInt
+		}
+	}
+	return chain.DefaultJoinLimit
+}
+
+type passThrough struct{}
+
+func (passThrough) Decorate(item RawItemR) (RawItemR, error) { return item, nil }
+
+func (passThrough) Stop() {}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+type mockDependent struct {
+	mockPerceptor
+	requires []Field
+	provides []Field
+	process  func(item RawItemR)
+	fail     func(item RawItemR) error
+}
+
+func (m *mockDependent) Requires() []Field { return m.requires }
+func (m *mockDependent) Provides() []Field { return m.provides }
+
+func (m *mockDependent) NewProcessor(chin <-chan RawItemR, chout chan<- RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, m)
+}
+
+func (m *mockDependent) Decorate(item RawItemR) (RawItemR, error) {
+	if m.process != nil {
+		m.process(item)
+	}
+	if m.fail != nil {
+		if err := m.fail(item); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+func (m *mockDependent) Stop() {}
+
+func dependent(name string, requires, provides []Field) *mockDependent {
+	return &mockDependent{mockPerceptor: mockPerceptor{name: name}, requires: requires, provides: provides}
+}
+
+func stageNames[P Perceptor](stages [][]P) [][]string {
+	res := make([][]string, len(stages))
+	for i, stage := range stages {
+		for _, p := range stage {
+			res[i] = append(res[i], p.Name())
+		}
+	}
+	return res
+}
+
+func TestSchedule(t *testing.T) {
+	t.Run("dependency order", func(t *testing.T) {
+		perceptors := []*mockDependent{
+			dependent("event", []Field{FieldDate, FieldLocation}, []Field{FieldMetadata}),
+			dependent("date", nil, []Field{FieldDate}),
+			dependent("geo", nil, []Field{FieldLocation}),
+			dependent("ratio", []Field{FieldSize}, []Field{FieldRatio}),
+			dependent("size", nil, []Field{FieldSize}),
+		}
+
+		stages, err := Schedule(perceptors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := stageNames(stages)
+		expected := [][]string{{"date", "geo", "size"}, {"event", "ratio"}}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		for i := range expected {
+			if len(got[i]) != len(expected[i]) {
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+			for j := range expected[i] {
+				if got[i][j] != expected[i][j] {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			}
+		}
+	})
+
+	t.Run("unmet requirement", func(t *testing.T) {
+		perceptors := []*mockDependent{
+			dependent("event", []Field{FieldDate}, nil),
+		}
+
+		if _, err := Schedule(perceptors); !errors.Is(err, ErrUnmetRequirement) {
+			t.Errorf("expected ErrUnmetRequirement, got %v", err)
+		}
+		if _, err := Schedule(perceptors, FieldDate); err != nil {
+			t.Errorf("available field should satisfy requirement, got %v", err)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		perceptors := []*mockDependent{
+			dependent("a", []Field{FieldDate}, []Field{FieldSize}),
+			dependent("b", []Field{FieldSize}, []Field{FieldDate}),
+			dependent("c", nil, nil),
+		}
+
+		if _, err := Schedule(perceptors); !errors.Is(err, ErrDependencyCycle) {
+			t.Errorf("expected ErrDependencyCycle, got %v", err)
+		}
+	})
+}
+
+func TestBuildChain(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(RawItemR) {
+		return func(RawItemR) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+		}
+	}
+
+	date := dependent("date", nil, []Field{FieldDate})
+	date.process = record("date")
+	size := dependent("size", nil, []Field{FieldSize})
+	size.process = record("size")
+	event := dependent("event", []Field{FieldDate, FieldSize}, nil)
+	event.process = record("event")
+
+	errch := make(chan error, 1)
+	ch := chain.NewChainProcessor(errch)
+	chin := make(chan RawItemR)
+	chout := make(chan RawItemR)
+
+	err := BuildChain(ch, []ExifPerceptor{event, date, size}, chin, chout, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ch.Process(ctx)
+
+	item := NewRawItem("a.jpg", nil)
+	chin <- item
+
+	select {
+	case got := <-chout:
+		if got != item {
+			t.Error("unexpected item")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for item")
+	}
+
+	if len(order) != 3 || order[2] != "event" {
+		t.Errorf("event should run after date and size, got %v", order)
+	}
+
+	close(chin)
+	select {
+	case _, ok := <-chout:
+		if ok {
+			t.Error("unexpected item")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output should be closed once input is drained")
+	}
+}
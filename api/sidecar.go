@@ -0,0 +1,185 @@
+package api
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dukobpa3/perceplib/exiftool"
+)
+
+// ExifSource tells where exif value was taken from
+type ExifSource int
+
+const (
+	NoSource ExifSource = iota
+	MainSource
+	SidecarSource
+)
+
+// SidecarPolicy defines precedence between main file and its sidecar
+type SidecarPolicy int
+
+const (
+	SidecarOverMain SidecarPolicy = iota
+	MainOverSidecar
+	MainOnly
+	SidecarOnly
+)
+
+// SidecarExifProvider is an ExifProvider which is aware about sidecars
+type SidecarExifProvider interface {
+	ExifProvider
+	// GetExifFrom returns exifdata with given key according to policy and the source it came from
+	GetExifFrom(key string, policy SidecarPolicy) (string, ExifSource)
+}
+
+// GetExif returns value with exactly given key or empty string.
+// Group qualified tags are found by bare names through items or ExifSources, see NewExifSources
+func (r RawExif) GetExif(key string) string {
+	v, _ := r.lookup(nil, key)
+	return v
+}
+
+// ExifSources keeps exif of main file together with exif of its sidecars.
+// Exif is indexed by NewExifSources, sources changed after it find qualified tags only by their keys
+type ExifSources struct {
+	Main    RawExif
+	Sidecar RawExif
+	Policy  SidecarPolicy // used by GetExif
+
+	mainIndex    map[string]string
+	sidecarIndex map[string]string
+}
+
+// NewExifSources indexes exif of both sources once, so group qualified tags are found by bare names as well
+func NewExifSources(main, sidecar RawExif, policy SidecarPolicy) *ExifSources {
+	return &ExifSources{
+		Main:         main,
+		Sidecar:      sidecar,
+		Policy:       policy,
+		mainIndex:    exiftool.Index(main),
+		sidecarIndex: exiftool.Index(sidecar),
+	}
+}
+
+func (e *ExifSources) GetExif(key string) string {
+	v, _ := e.GetExifFrom(key, e.Policy)
+	return v
+}
+
+func (e *ExifSources) GetExifFrom(key string, policy SidecarPolicy) (string, ExifSource) {
+	var order []ExifSource
+	switch policy {
+	case SidecarOverMain:
+		order = []ExifSource{SidecarSource, MainSource}
+	case MainOverSidecar:
+		order = []ExifSource{MainSource, SidecarSource}
+	case MainOnly:
+		order = []ExifSource{MainSource}
+	case SidecarOnly:
+		order = []ExifSource{SidecarSource}
+	}
+
+	for _, src := range order {
+		exif, index := e.Main, e.mainIndex
+		if src == SidecarSource {
+			exif, index = e.Sidecar, e.sidecarIndex
+		}
+		if v, ok := exif.lookup(index, key); ok {
+			return v, src
+		}
+	}
+	return "", NoSource
+}
+
+// Merged returns copy of exif as GetExif sees it, values of main file and sidecar are merged according to Policy.
+// Tags of the preferred source replace the same tags of the other one even if their groups differ
+func (e *ExifSources) Merged() RawExif {
+	var lower, upper RawExif
+	switch e.Policy {
+	case SidecarOverMain:
+		lower, upper = e.Main, e.Sidecar
+	case MainOverSidecar:
+		lower, upper = e.Sidecar, e.Main
+	case MainOnly:
+		upper = e.Main
+	case SidecarOnly:
+		upper = e.Sidecar
+	}
+
+	res := make(RawExif, len(lower)+len(upper))
+	replaced := upper.tags()
+	for k, v := range lower {
+		if _, tag := SplitExifKey(k); !replaced[tag] {
+			res[k] = v
+		}
+	}
+	maps.Copy(res, upper)
+	return res
+}
+
+// FindSidecars returns existing xmp sidecars of the file.
+// Darktable style "file.ext.xmp" goes first as it can't be shared between files with different extensions,
+// then "file.xmp"
+func FindSidecars(path string) []string {
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, ".xmp") {
+		return nil
+	}
+
+	base := strings.TrimSuffix(path, ext)
+	candidates := []string{
+		path + ".xmp", path + ".XMP",
+		base + ".xmp", base + ".XMP",
+	}
+
+	var res []os.FileInfo
+	var paths []string
+	for _, c := range candidates {
+		info, err := os.Stat(c)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// case insensitive file systems report the same file twice
+		if containsSameFile(res, info) {
+			continue
+		}
+		res = append(res, info)
+		paths = append(paths, c)
+	}
+	return paths
+}
+
+func containsSameFile(infos []os.FileInfo, info os.FileInfo) bool {
+	for _, i := range infos {
+		if os.SameFile(i, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadSidecars finds sidecars of the file and reads them with given server.
+// Values from sidecars found earlier take precedence, see FindSidecars.
+// Server should be created without custom split function
+func ReadSidecars(server *exiftool.Server, path string, arg ...string) (RawExif, []string, error) {
+	sidecars := FindSidecars(path)
+	if len(sidecars) == 0 {
+		return nil, nil, nil
+	}
+
+	res := make(RawExif)
+	for i := len(sidecars) - 1; i >= 0; i-- {
+		out, err := server.Command(slices.Concat(arg, sidecars[i:i+1])...)
+		if err != nil {
+			return nil, sidecars, err
+		}
+		if err := exiftool.Unmarshal(out, res); err != nil {
+			return nil, sidecars, err
+		}
+	}
+	return res, sidecars, nil
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExifSources(t *testing.T) {
+	sources := NewExifSources(RawExif{
+		"EXIF:Rating": []byte("1"),
+		"EXIF:Make":   []byte("Canon"),
+		"XMP:Make":    []byte("Nikon"),
+	}, RawExif{
+		"XMP:Rating": []byte("5"),
+		"XMP:Label":  []byte("Red"),
+	}, SidecarOverMain)
+
+	tests := []struct {
+		name   string
+		key    string
+		policy SidecarPolicy
+		value  string
+		source ExifSource
+	}{
+		{"sidecar over main", "Rating", SidecarOverMain, "5", SidecarSource},
+		{"sidecar over main fallback", "Make", SidecarOverMain, "Canon", MainSource},
+		{"main over sidecar", "Rating", MainOverSidecar, "1", MainSource},
+		{"main over sidecar fallback", "Label", MainOverSidecar, "Red", SidecarSource},
+		{"main only", "Label", MainOnly, "", NoSource},
+		{"sidecar only", "Make", SidecarOnly, "", NoSource},
+		{"missing", "Model", SidecarOverMain, "", NoSource},
+		{"qualified", "EXIF:Rating", SidecarOverMain, "1", MainSource},
+		{"qualified lower priority", "XMP:Make", SidecarOverMain, "Nikon", MainSource},
+		{"other group", "IPTC:Rating", SidecarOverMain, "", NoSource},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, source := sources.GetExifFrom(tt.key, tt.policy)
+			if value != tt.value || source != tt.source {
+				t.Errorf("expected %q from %d, got %q from %d", tt.value, tt.source, value, source)
+			}
+		})
+	}
+
+	sources.Policy = MainOverSidecar
+	if v := sources.GetExif("Rating"); v != "1" {
+		t.Errorf("GetExif should use configured policy, got %q", v)
+	}
+
+	for _, policy := range []SidecarPolicy{SidecarOverMain, MainOverSidecar, MainOnly, SidecarOnly} {
+		sources.Policy = policy
+		merged := sources.Merged()
+		indexed := NewExifSources(merged, nil, MainOnly)
+		for _, key := range []string{"Rating", "Make", "Label"} {
+			if v, _ := sources.GetExifFrom(key, policy); indexed.GetExif(key) != v {
+				t.Errorf("policy %d: merged %s should be %q, got %q", policy, key, v, indexed.GetExif(key))
+			}
+		}
+		if len(merged) > 4 {
+			t.Errorf("policy %d: tags of the other source should be replaced, got %v", policy, merged)
+		}
+	}
+}
+
+func TestFindSidecars(t *testing.T) {
+	dir := t.TempDir()
+	touch := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	img := touch("IMG_0001.CR2")
+	darktable := touch("IMG_0001.CR2.xmp")
+	lightroom := touch("IMG_0001.xmp")
+	touch("IMG_0002.xmp")
+
+	got := FindSidecars(img)
+	if len(got) != 2 || got[0] != darktable || got[1] != lightroom {
+		t.Errorf("unexpected sidecars %v", got)
+	}
+
+	if got := FindSidecars(touch("IMG_0003.JPG")); len(got) != 0 {
+		t.Errorf("expected no sidecars, got %v", got)
+	}
+
+	if got := FindSidecars(lightroom); len(got) != 0 {
+		t.Errorf("sidecar should not have sidecars, got %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Orientation is an exif orientation, values from 1 to 8 as defined by exif specification
+type Orientation int
+
+const (
+	OrientationUnknown Orientation = iota
+	OrientationNormal
+	OrientationMirrorHorizontal
+	OrientationRotate180
+	OrientationMirrorVertical
+	OrientationMirrorHorizontalRotate270
+	OrientationRotate90
+	OrientationMirrorHorizontalRotate90
+	OrientationRotate270
+)
+
+var orientationNames = map[string]Orientation{
+	"horizontal (normal)":                 OrientationNormal,
+	"mirror horizontal":                   OrientationMirrorHorizontal,
+	"rotate 180":                          OrientationRotate180,
+	"mirror vertical":                     OrientationMirrorVertical,
+	"mirror horizontal and rotate 270 cw": OrientationMirrorHorizontalRotate270,
+	"rotate 90 cw":                        OrientationRotate90,
+	"mirror horizontal and rotate 90 cw":  OrientationMirrorHorizontalRotate90,
+	"rotate 270 cw":                       OrientationRotate270,
+}
+
+// ParseOrientation parses exiftool printed ("Rotate 90 CW") or numeric ("6") orientation
+func ParseOrientation(value string) (Orientation, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if o, ok := orientationNames[v]; ok {
+		return o, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < int(OrientationNormal) || n > int(OrientationRotate270) {
+		return OrientationUnknown, ErrExifMalformed
+	}
+	return Orientation(n), nil
+}
+
+// OrientationFromRotation converts video rotation in degrees clockwise to orientation
+func OrientationFromRotation(degrees int) (Orientation, error) {
+	switch ((degrees % 360) + 360) % 360 {
+	case 0:
+		return OrientationNormal, nil
+	case 90:
+		return OrientationRotate90, nil
+	case 180:
+		return OrientationRotate180, nil
+	case 270:
+		return OrientationRotate270, nil
+	}
+	return OrientationUnknown, ErrExifMalformed
+}
+
+// SwapsDimensions reports whether displayed image has width and height swapped
+func (o Orientation) SwapsDimensions() bool {
+	return o >= OrientationMirrorHorizontalRotate270 && o <= OrientationRotate270
+}
+
+// ExifOrientation returns orientation of still image or rotation of video.
+// Missing or malformed values are treated as normal orientation
+func ExifOrientation(p ExifProvider) Orientation {
+	if v, err := ExifString(p, "Orientation"); err == nil {
+		if o, err := ParseOrientation(v); err == nil {
+			return o
+		}
+	}
+
+	if deg, err := ExifInt(p, "Rotation"); err == nil {
+		if o, err := OrientationFromRotation(int(deg)); err == nil {
+			return o
+		}
+	}
+
+	return OrientationNormal
+}
+
+// ExifSize returns stored (not oriented) size from ImageWidth and ImageHeight,
+// falls back to ExifImageWidth, ExifImageHeight and then to composite ImageSize.
+// Videos get size of video track, exiftool prefers it over sizes of embedded stills
+func ExifSize(p ExifProvider) (Size, error) {
+	pairs := [][2]string{
+		{"ImageWidth", "ImageHeight"},
+		{"ExifImageWidth", "ExifImageHeight"},
+	}
+
+	var firstErr error
+	for _, pair := range pairs {
+		size, err := exifSizePair(p, pair[0], pair[1])
+		if err == nil {
+			return size, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if v, err := ExifString(p, "ImageSize"); err == nil {
+		ws, hs, ok := strings.Cut(strings.Replace(v, " ", "x", 1), "x")
+		w, errW := strconv.Atoi(ws)
+		h, errH := strconv.Atoi(hs)
+		if !ok || errW != nil || errH != nil || w <= 0 || h <= 0 {
+			return Size{}, malformed("ImageSize", v)
+		}
+		return Size{W: w, H: h}, nil
+	}
+
+	return Size{}, firstErr
+}
+
+func exifSizePair(p ExifProvider, wKey, hKey string) (Size, error) {
+	w, err := ExifInt(p, wKey)
+	if err != nil {
+		return Size{}, err
+	}
+	if w <= 0 {
+		return Size{}, malformed(wKey, p.GetExif(wKey))
+	}
+
+	h, err := ExifInt(p, hKey)
+	if err != nil {
+		return Size{}, err
+	}
+	if h <= 0 {
+		return Size{}, malformed(hKey, p.GetExif(hKey))
+	}
+
+	return Size{W: int(w), H: int(h)}, nil
+}
+
+// OrientedSize returns size as it is displayed with given orientation
+func OrientedSize(size Size, o Orientation) Size {
+	if o.SwapsDimensions() {
+		return Size{W: size.H, H: size.W}
+	}
+	return size
+}
+
+// OrientedDataProvider gives both stored and displayed variants of item size,
+// GetSize and GetRatio of ItemDataProvider are stored ones
+type OrientedDataProvider interface {
+	GetOrientation() Orientation
+	GetDisplaySize() Size
+	GetDisplayRatio() Size
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name     string
+		exif     RawExif
+		expected Orientation
+		swaps    bool
+	}{
+		{"printed", exifOf("Orientation", "Rotate 90 CW"), OrientationRotate90, true},
+		{"numeric", exifOf("Orientation", "8"), OrientationRotate270, true},
+		{"normal", exifOf("Orientation", "Horizontal (normal)"), OrientationNormal, false},
+		{"upside down", exifOf("Orientation", "Rotate 180"), OrientationRotate180, false},
+		{"video rotation", exifOf("Rotation", "90"), OrientationRotate90, true},
+		{"missing", exifOf(), OrientationNormal, false},
+		{"malformed", exifOf("Orientation", "Unknown (0)"), OrientationNormal, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := ExifOrientation(tt.exif)
+			if o != tt.expected || o.SwapsDimensions() != tt.swaps {
+				t.Errorf("expected %d (swap %v), got %d (swap %v)", tt.expected, tt.swaps, o, o.SwapsDimensions())
+			}
+		})
+	}
+}
+
+func TestExifSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		exif     RawExif
+		expected Size
+		err      error
+	}{
+		{"image size tags", exifOf("ImageWidth", "6000", "ImageHeight", "4000"), Size{6000, 4000}, nil},
+		{"exif size tags", exifOf("ExifImageWidth", "4032", "ExifImageHeight", "3024"), Size{4032, 3024}, nil},
+		{"composite", exifOf("ImageSize", "1920x1080"), Size{1920, 1080}, nil},
+		{"composite numeric", exifOf("ImageSize", "1920 1080"), Size{1920, 1080}, nil},
+		{"zero", exifOf("ImageWidth", "0", "ImageHeight", "0"), Size{}, ErrExifMalformed},
+		{"missing", exifOf(), Size{}, ErrExifMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := ExifSize(tt.exif)
+			if !errors.Is(err, tt.err) || size != tt.expected {
+				t.Errorf("expected %v (%v), got %v (%v)", tt.expected, tt.err, size, err)
+			}
+		})
+	}
+}
+
+func TestRawItemDisplaySize(t *testing.T) {
+	item := NewRawItem("portrait.jpg", exifOf("Orientation", "Rotate 90 CW"))
+	item.SetSize(Size{W: 6000, H: 4000})
+	item.SetRatio(Size{W: 3, H: 2})
+
+	if item.GetDisplaySize() != (Size{W: 4000, H: 6000}) {
+		t.Errorf("unexpected display size %v", item.GetDisplaySize())
+	}
+	if item.GetDisplayRatio() != (Size{W: 2, H: 3}) {
+		t.Errorf("unexpected display ratio %v", item.GetDisplayRatio())
+	}
+	if item.GetSize() != (Size{W: 6000, H: 4000}) {
+		t.Error("stored size should stay as is")
+	}
+}
+
+func TestEditorOf(t *testing.T) {
+	item := NewRawItem("a.jpg", nil)
+	wrapped := NewFilePool(0).Wrap(Enrich(item), "a.jpg")
+
+	editor, ok := EditorOf(wrapped, "exif_size")
+	if !ok {
+		t.Fatal("editor of wrapped item should be found")
+	}
+	editor.SetSize(Size{W: 1, H: 1})
+
+	if item.SetBy(FieldSize) != "exif_size" {
+		t.Error("change should be recorded on behalf of perceptor")
+	}
+	if _, ok := EditorOf(&mockItem{}, "exif_size"); ok {
+		t.Error("read only item has no editor")
+	}
+}
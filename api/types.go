@@ -1,6 +1,9 @@
 package api
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type RawExif map[string][]byte
 
@@ -10,8 +13,8 @@ type Size struct {
 }
 
 type ExifProvider interface {
-	//returns exifdata with given key from main file
-	//todo add support for sidecars
+	//returns exifdata with given key,
+	//items with sidecars resolve it according to their policy, see SidecarExifProvider
 	GetExif(key string) string
 }
 
@@ -28,7 +31,81 @@ type ItemDataEditor interface {
 	SetRatio(ratio Size)
 }
 
+type GuidEditor interface {
+	SetGuid(guid string)
+}
+
 type RawItemR interface {
 	ItemDataProvider
 	ExifProvider
 }
+
+// RawContent gives access to file bytes.
+// File is opened lazily on first read and must be closed after use to release the slot in FilePool
+type RawContent interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+type RawContentProvider interface {
+	// GetContent returns new independent handle to file contents on every call
+	GetContent() RawContent
+}
+
+type RawDataItemR interface {
+	RawItemR
+	RawContentProvider
+}
+
+// Location is a geographic position in decimal degrees, altitude in meters
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// Metadata is a derived information about item, e.g. classification results
+type Metadata struct {
+	Tags       []string
+	Categories []string
+	Event      string
+}
+
+// MetadataDataProvider exposes results of earlier perceptors
+type MetadataDataProvider interface {
+	GetLocation() (Location, bool)
+	GetMetadata() Metadata
+}
+
+type MetadataDataEditor interface {
+	SetLocation(location Location)
+	AddTags(tags ...string)
+	AddCategories(categories ...string)
+	SetEvent(event string)
+}
+
+type MetadataItemR interface {
+	RawItemR
+	MetadataDataProvider
+}
+
+// ItemGroupR is a set of items which should be processed together (burst, folder, event etc.)
+type ItemGroupR interface {
+	GetGroupID() string
+	GetItems() []RawItemR
+}
+
+// Group is a basic ItemGroupR implementation
+type Group struct {
+	ID    string
+	Items []RawItemR
+}
+
+func (g *Group) GetGroupID() string {
+	return g.ID
+}
+
+func (g *Group) GetItems() []RawItemR {
+	return g.Items
+}
@@ -11,8 +11,13 @@ func gcd(a, b int) int {
 	return a
 }
 
+// GetRatio reduces size by greatest common divisor, zero size gives zero ratio.
+// Use RatioClassifier to get the nearest standard ratio
 func GetRatio(size Size) Size {
 	gcd := gcd(size.W, size.H)
+	if gcd == 0 {
+		return Size{}
+	}
 	return Size{
 		W: size.W / gcd,
 		H: size.H / gcd,
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VideoInfo is a technical description of video file
+type VideoInfo struct {
+	Duration  time.Duration
+	FrameRate float64 // frames per second, zero if unknown
+	Codec     string  // video codec, e.g. "avc1", "hvc1" or "V_MPEG4/ISO/AVC"
+	Rotation  int     // degrees clockwise
+	HasAudio  bool
+	Created   time.Time // creation date in UTC, zero if unknown
+	LocalZone bool      // creation date was stored with local offset, e.g. by Apple devices
+	Container string    // group of container tags, "QuickTime" or "Matroska"
+}
+
+// VideoProvider is implemented by items which recognize videos
+type VideoProvider interface {
+	// GetVideo returns video description, false for still images
+	GetVideo() (VideoInfo, bool)
+}
+
+// IsVideo reports whether exif belongs to video file
+func IsVideo(p ExifProvider) bool {
+	return strings.HasPrefix(strings.ToLower(p.GetExif("MIMEType")), "video/")
+}
+
+// quickTimeEpoch is a zero value written by some cameras instead of real date
+var quickTimeEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	videoCodecKeys = []string{"CompressorID", "CodecID", "VideoCodec"}
+	audioKeys      = []string{"AudioFormat", "AudioChannels", "AudioSampleRate"}
+	frameRateKeys  = []string{"VideoFrameRate", "FrameRate"}
+	durationKeys   = []string{"Duration", "MediaDuration"}
+
+	// utcDateKeys are container dates stored in UTC
+	utcDateKeys = []string{
+		"QuickTime:CreateDate", "QuickTime:MediaCreateDate", "QuickTime:TrackCreateDate",
+		"Matroska:DateTimeOriginal",
+	}
+	// ungroupedDateKeys match dates of any group, used for videos read without -G
+	ungroupedDateKeys = []string{"CreateDate", "MediaCreateDate", "TrackCreateDate"}
+)
+
+func firstExif(p ExifProvider, keys []string) (string, bool) {
+	for _, key := range keys {
+		if v, err := ExifString(p, key); err == nil && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ExifVideo describes video from QuickTime or Matroska tags, ErrExifMissing for still images
+func ExifVideo(p ExifProvider) (VideoInfo, error) {
+	if !IsVideo(p) {
+		return VideoInfo{}, missing("MIMEType")
+	}
+
+	var v VideoInfo
+	v.Container = "QuickTime"
+	if mime := strings.ToLower(p.GetExif("MIMEType")); strings.Contains(mime, "matroska") || strings.Contains(mime, "webm") {
+		v.Container = "Matroska"
+	}
+
+	if s, ok := firstExif(p, durationKeys); ok {
+		v.Duration, _ = ParseExifDuration(s)
+	}
+	for _, key := range frameRateKeys {
+		if f, err := ExifFloat(p, key); err == nil && f > 0 {
+			v.FrameRate = f
+			break
+		}
+	}
+	v.Codec, _ = firstExif(p, videoCodecKeys)
+	if s, ok := firstExif(p, audioKeys); ok && s != "0" {
+		v.HasAudio = true
+	}
+	if deg, err := ExifInt(p, "Rotation"); err == nil {
+		v.Rotation = int(deg)
+	}
+
+	v.Created, v.LocalZone, _ = ExifVideoDate(p)
+	v.Created = v.Created.UTC()
+	return v, nil
+}
+
+// ExifVideoDate returns creation date of video.
+// Apple CreationDate keeps local offset and wins, container dates are stored in UTC.
+// Zero dates written by some cameras are skipped
+func ExifVideoDate(p ExifProvider) (t time.Time, local bool, err error) {
+	if t, hasZone, err := ExifDateTime(p, "CreationDate", "", "", time.UTC); err == nil && hasZone {
+		return t, true, nil
+	}
+
+	keys := utcDateKeys
+	if IsVideo(p) {
+		keys = append(keys[:len(keys):len(keys)], ungroupedDateKeys...)
+	}
+	for _, key := range keys {
+		t, err := ExifTime(p, key)
+		if err != nil || !t.After(quickTimeEpoch) {
+			continue
+		}
+		return t.UTC(), false, nil
+	}
+	return time.Time{}, false, missing("CreateDate")
+}
+
+var durationRe = regexp.MustCompile(`^(?:(?:(\d+):)?(\d+):)?(\d+(?:\.\d+)?)\s*s?(?:\s*\(approx\))?$`)
+
+// ParseExifDuration parses exiftool durations like "12.35 s", "0:01:23" or "1:02:03.5"
+func ParseExifDuration(value string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, ErrExifMalformed
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	sec, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return 0, ErrExifMalformed
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec*float64(time.Second))
+	return d.Round(time.Millisecond), nil
+}
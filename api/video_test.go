@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExifDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"12.35 s":          12350 * time.Millisecond,
+		"0:01:23":          83 * time.Second,
+		"1:02:03.5":        time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		"0 s":              0,
+		"7.01 s (approx)":  7010 * time.Millisecond,
+		"0:00:30 (approx)": 30 * time.Second,
+	}
+	for v, expected := range tests {
+		d, err := ParseExifDuration(v)
+		if err != nil || d != expected {
+			t.Errorf("%q: expected %v, got %v %v", v, expected, d, err)
+		}
+	}
+	if _, err := ParseExifDuration("long"); !errors.Is(err, ErrExifMalformed) {
+		t.Errorf("expected ErrExifMalformed, got %v", err)
+	}
+}
+
+func TestExifVideo(t *testing.T) {
+	item, err := ParseRawItem([]byte("======== ./IMG_0001.MOV\n" +
+		"[File]          MIMEType                        : video/quicktime\n" +
+		"[QuickTime]     CreateDate                      : 2024:06:01 07:20:30\n" +
+		"[QuickTime]     CreationDate                    : 2024:06:01 10:20:30+03:00\n" +
+		"[QuickTime]     Duration                        : 0:00:42\n" +
+		"[QuickTime]     CompressorID                    : hvc1\n" +
+		"[QuickTime]     VideoFrameRate                  : 29.97\n" +
+		"[QuickTime]     AudioFormat                     : mp4a\n" +
+		"[QuickTime]     ImageWidth                      : 1920\n" +
+		"[QuickTime]     ImageHeight                     : 1080\n" +
+		"[Composite]     Rotation                        : 90\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok := item.GetVideo()
+	if !ok {
+		t.Fatal("item should be a video")
+	}
+	expected := VideoInfo{
+		Duration:  42 * time.Second,
+		FrameRate: 29.97,
+		Codec:     "hvc1",
+		Rotation:  90,
+		HasAudio:  true,
+		Created:   time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+		LocalZone: true,
+		Container: "QuickTime",
+	}
+	if v != expected {
+		t.Errorf("expected %+v, got %+v", expected, v)
+	}
+
+	if item.GetExif("ImageWidth") != "1920" || item.GetExif("QuickTime:CreateDate") != "2024:06:01 07:20:30" {
+		t.Error("tags should be found both qualified and unqualified")
+	}
+	if exif := item.GetRawExif(); len(exif) != 10 || exif["CreateDate"] != nil {
+		t.Errorf("tags should be kept as printed, got %d", len(exif))
+	}
+	if size, err := ExifSize(item); err != nil || size != (Size{W: 1920, H: 1080}) {
+		t.Errorf("unexpected size %v %v", size, err)
+	}
+	if item.GetOrientation() != OrientationRotate90 {
+		t.Errorf("unexpected orientation %d", item.GetOrientation())
+	}
+
+	if _, ok := NewRawItem("a.jpg", exifOf("MIMEType", "image/jpeg")).GetVideo(); ok {
+		t.Error("still image is not a video")
+	}
+}
+
+func TestExifVideoDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		exif     RawExif
+		expected time.Time
+		local    bool
+	}{
+		{
+			name:     "zero dates are skipped",
+			exif:     exifOf("MIMEType", "video/mp4", "CreateDate", "0000:00:00 00:00:00", "MediaCreateDate", "2024:06:01 07:20:30"),
+			expected: time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "matroska",
+			exif:     exifOf("MIMEType", "video/x-matroska", "Matroska:DateTimeOriginal", "2024:06:01 07:20:30Z"),
+			expected: time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "apple",
+			exif:     exifOf("MIMEType", "video/quicktime", "CreationDate", "2024:06:01 10:20:30+03:00", "CreateDate", "2024:06:01 07:20:00"),
+			expected: time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+			local:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, local, err := ExifVideoDate(tt.exif)
+			if err != nil || !d.Equal(tt.expected) || local != tt.local {
+				t.Errorf("expected %v (local %v), got %v (local %v) %v", tt.expected, tt.local, d, local, err)
+			}
+		})
+	}
+
+	if _, _, err := ExifVideoDate(exifOf("CreateDate", "2024:06:01 07:20:30")); !errors.Is(err, ErrExifMissing) {
+		t.Errorf("ungrouped dates of stills are not video dates, got %v", err)
+	}
+}
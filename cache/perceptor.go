@@ -0,0 +1,356 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+// DefaultGuidStrategy takes guid set by earlier perceptors and falls back to hashes of image data
+var DefaultGuidStrategy = api.FirstGuid(ItemGuid(), api.ImageDataHashGuid(), api.ContentGuid())
+
+// ItemGuid takes guid already set to the item, e.g. by item_guid perceptor
+func ItemGuid() api.GuidStrategy {
+	return api.GuidFunc(func(item api.RawItemR) (string, error) {
+		if guid := item.GetGuid(); guid != "" {
+			return guid, nil
+		}
+		return "", api.ErrNoGuid
+	})
+}
+
+// Perceptor wraps api.ExifPerceptor, items already processed by the same perceptor version
+// get stored result and fields without processing. Items without guid are always processed.
+// Fields declared by api.Dependent.Provides are cached, only metadata added by the perceptor is cached
+type Perceptor struct {
+	api.ExifPerceptor
+	Store *Store
+	Guid  api.GuidStrategy // DefaultGuidStrategy if nil
+
+	config string // fingerprint of applied config
+}
+
+var (
+	_ api.ExifPerceptor = (*Perceptor)(nil)
+	_ api.Versioned     = (*Perceptor)(nil)
+	_ api.Dependent     = (*Perceptor)(nil)
+	_ api.Configurable  = (*Perceptor)(nil)
+)
+
+func Wrap(p api.ExifPerceptor, store *Store) *Perceptor {
+	return &Perceptor{ExifPerceptor: p, Store: store}
+}
+
+// Unwrap returns wrapped perceptor
+func (p *Perceptor) Unwrap() api.ExifPerceptor {
+	return p.ExifPerceptor
+}
+
+// Version is a version of wrapped perceptor, with fingerprint of its config if it was configured,
+// so results made with other settings are not reused
+func (p *Perceptor) Version() string {
+	v := api.PerceptorVersion(p.ExifPerceptor)
+	if p.config != "" {
+		v += "+" + p.config
+	}
+	return v
+}
+
+func (p *Perceptor) Requires() []api.Field {
+	if d, ok := p.ExifPerceptor.(api.Dependent); ok {
+		return d.Requires()
+	}
+	return nil
+}
+
+func (p *Perceptor) Provides() []api.Field {
+	if d, ok := p.ExifPerceptor.(api.Dependent); ok {
+		return d.Provides()
+	}
+	return nil
+}
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	if c, ok := p.ExifPerceptor.(api.Configurable); ok {
+		return c.ConfigSchema()
+	}
+	return nil
+}
+
+// Clone wraps clone of configurable perceptor, so they are configured together
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	if cl, ok := p.ExifPerceptor.(api.Configurable); ok {
+		if inner, ok := cl.Clone().(api.ExifPerceptor); ok {
+			c.ExifPerceptor = inner
+		}
+	}
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	c, ok := p.ExifPerceptor.(api.Configurable)
+	if !ok {
+		return nil
+	}
+	if err := c.Configure(cfg); err != nil {
+		return err
+	}
+
+	// map keys are sorted by json, so equal configs have equal fingerprints
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	p.config = hex.EncodeToString(sum[:4])
+	return nil
+}
+
+func (p *Perceptor) guid() api.GuidStrategy {
+	if p.Guid == nil {
+		return DefaultGuidStrategy
+	}
+	return p.Guid
+}
+
+// key returns cache key of the item, false if item has no guid
+func (p *Perceptor) key(item api.RawItemR) (Key, bool) {
+	guid, err := p.guid().Guid(item)
+	if err != nil {
+		return Key{}, false
+	}
+	return Key{Guid: guid, Perceptor: p.Name(), Version: p.Version()}, true
+}
+
+// NewProcessor sends cache misses to processor of wrapped perceptor and stores its output,
+// cache hits go around it. Inner channels are closed when chin is drained, chout is left to the caller
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	miss := make(chan api.RawItemR)
+	hit := make(chan api.RawItemR)
+	processed := make(chan api.RawItemR)
+	misses := &misses{items: make(map[api.RawItemR]missed)}
+
+	ch := chain.NewChainProcessor(nil)
+	ch.AddStep(chain.WithClose(chain.NewSwitch(chin, []chan<- api.RawItemR{miss, hit}, &lookup{perceptor: p, misses: misses}), func() {
+		close(miss)
+		close(hit)
+	}))
+	inner := chain.WithErrorHook(p.ExifPerceptor.NewProcessor(miss, processed, logger), func(input any, err error) {
+		if item, ok := input.(api.RawItemR); ok {
+			misses.take(item)
+		}
+	})
+	ch.AddStep(chain.WithClose(inner, func() { close(processed) }))
+	ch.AddStep(chain.NewDecorator(processed, chout, &store{perceptor: p, misses: misses, logger: logger}))
+	ch.AddStep(chain.NewDecorator(hit, chout, passThrough{}))
+	return ch
+}
+
+const (
+	branchMiss = iota
+	branchHit
+)
+
+// missed is a cache miss waiting for output of wrapped perceptor
+type missed struct {
+	key      Key
+	metadata api.Metadata // metadata before processing, only changes of the perceptor are cached
+}
+
+// misses passes cache misses from lookup to store, so the key is computed once per item.
+// Items failed by wrapped perceptor are forgotten with chain.WithErrorHook
+type misses struct {
+	mu    sync.Mutex
+	items map[api.RawItemR]missed
+}
+
+func (m *misses) add(item api.RawItemR, miss missed) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[item] = miss
+}
+
+func (m *misses) take(item api.RawItemR) (missed, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	miss, ok := m.items[item]
+	delete(m.items, item)
+	return miss, ok
+}
+
+type lookup struct {
+	perceptor *Perceptor
+	misses    *misses
+}
+
+func (lk *lookup) Switch(item api.RawItemR) (map[int]api.RawItemR, error) {
+	key, ok := lk.perceptor.key(item)
+	if !ok {
+		return map[int]api.RawItemR{branchMiss: item}, nil
+	}
+
+	e, ok := lk.perceptor.Store.Get(key)
+	if !ok {
+		miss := missed{key: key}
+		if mp, ok := api.MetadataOf(item); ok {
+			miss.metadata = mp.GetMetadata()
+		}
+		lk.misses.add(item, miss)
+		return map[int]api.RawItemR{branchMiss: item}, nil
+	}
+
+	if err := apply(item, lk.perceptor.Name(), e); err != nil {
+		return nil, fmt.Errorf("%s: cache: %w", lk.perceptor.Name(), err)
+	}
+	return map[int]api.RawItemR{branchHit: item}, nil
+}
+
+func (lk *lookup) Stop() {}
+
+type store struct {
+	perceptor *Perceptor
+	misses    *misses
+	logger    *l.Logger
+}
+
+// Decorate stores output of wrapped perceptor, item goes further even if it can't be stored
+func (st *store) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	miss, ok := st.misses.take(item)
+	if !ok {
+		return item, nil
+	}
+
+	e := &Entry{Key: miss.key, Fields: snapshot(item, st.perceptor.Provides(), miss.metadata)}
+	if rs, ok := api.ResultsOf(item); ok {
+		e.Result, _ = rs.Get(miss.key.Perceptor)
+	}
+
+	if err := st.perceptor.Store.Put(e); err != nil && st.logger != nil {
+		st.logger.Warn("cache: can't store output", l.String("perceptor", miss.key.Perceptor), l.String("guid", miss.key.Guid), l.Error(err))
+	}
+	return item, nil
+}
+
+func (st *store) Stop() {}
+
+type passThrough struct{}
+
+func (passThrough) Decorate(item api.RawItemR) (api.RawItemR, error) { return item, nil }
+
+func (passThrough) Stop() {}
+
+// snapshot takes provided fields of the item, metadata is taken as changes made since before
+func snapshot(item api.RawItemR, provides []api.Field, before api.Metadata) Fields {
+	var f Fields
+	if slices.Contains(provides, api.FieldGuid) {
+		f.Guid = item.GetGuid()
+	}
+	if slices.Contains(provides, api.FieldDate) {
+		date := item.GetDate()
+		f.Date = &date
+	}
+	if slices.Contains(provides, api.FieldSize) {
+		size := item.GetSize()
+		f.Size = &size
+	}
+	if slices.Contains(provides, api.FieldRatio) {
+		ratio := item.GetRatio()
+		f.Ratio = &ratio
+	}
+
+	mp, ok := api.MetadataOf(item)
+	if !ok {
+		return f
+	}
+	if slices.Contains(provides, api.FieldLocation) {
+		if location, ok := mp.GetLocation(); ok {
+			f.Location = &location
+		}
+	}
+	if slices.Contains(provides, api.FieldMetadata) {
+		metadata := changed(before, mp.GetMetadata())
+		f.Metadata = &metadata
+	}
+	return f
+}
+
+// changed returns tags and categories added since before, and event if it was changed
+func changed(before, after api.Metadata) api.Metadata {
+	var res api.Metadata
+	for _, tag := range after.Tags {
+		if !slices.Contains(before.Tags, tag) {
+			res.Tags = append(res.Tags, tag)
+		}
+	}
+	for _, c := range after.Categories {
+		if !slices.Contains(before.Categories, c) {
+			res.Categories = append(res.Categories, c)
+		}
+	}
+	if after.Event != before.Event {
+		res.Event = after.Event
+	}
+	return res
+}
+
+// apply sets cached fields and result to the item on behalf of the perceptor
+func apply(item api.RawItemR, perceptor string, e *Entry) error {
+	if e.Result != nil {
+		if err := api.CheckResults(item); err != nil {
+			return err
+		}
+	}
+	editor, ok := api.EditorOf(item, perceptor)
+	if !ok {
+		return errors.New("item is read only")
+	}
+
+	f := e.Fields
+	if f.Guid != "" {
+		ge, ok := editor.(api.GuidEditor)
+		if !ok {
+			return errors.New("item doesn't support guid changes")
+		}
+		ge.SetGuid(f.Guid)
+	}
+	if f.Date != nil {
+		editor.SetDate(*f.Date)
+	}
+	if f.Size != nil {
+		editor.SetSize(*f.Size)
+	}
+	if f.Ratio != nil {
+		editor.SetRatio(*f.Ratio)
+	}
+
+	if f.Location != nil || f.Metadata != nil {
+		me, ok := editor.(api.MetadataDataEditor)
+		if !ok {
+			return errors.New("item doesn't support metadata changes")
+		}
+		if f.Location != nil {
+			me.SetLocation(*f.Location)
+		}
+		if f.Metadata != nil {
+			me.AddTags(f.Metadata.Tags...)
+			me.AddCategories(f.Metadata.Categories...)
+			if f.Metadata.Event != "" {
+				me.SetEvent(f.Metadata.Event)
+			}
+		}
+	}
+
+	if e.Result != nil {
+		return api.SetResult(item, e.Result)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+	"github.com/dukobpa3/perceplib/perceptors/exif_size"
+)
+
+type countingPerceptor struct {
+	calls   atomic.Int32
+	version string
+}
+
+func (p *countingPerceptor) Name() string                       { return "counting" }
+func (p *countingPerceptor) Version() string                    { return p.version }
+func (p *countingPerceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *countingPerceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+func (p *countingPerceptor) Requires() []api.Field              { return nil }
+func (p *countingPerceptor) Provides() []api.Field {
+	return []api.Field{api.FieldSize, api.FieldMetadata}
+}
+
+func (p *countingPerceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, p)
+}
+
+func (p *countingPerceptor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	p.calls.Add(1)
+	editor, _ := api.EditorOf(item, p.Name())
+	editor.SetSize(api.Size{W: 3, H: 2})
+	editor.(api.MetadataDataEditor).AddTags("counted")
+	api.SetResult(item, api.NewResult(p).AddTags("landscape"))
+	return item, nil
+}
+
+func (p *countingPerceptor) Stop() {}
+
+// run passes items through the perceptor and returns them
+func run(t *testing.T, p api.ExifPerceptor, items ...api.RawItemR) []api.RawItemR {
+	t.Helper()
+
+	errch := make(chan error, len(items))
+	ch := chain.NewChainProcessor(errch)
+	chin := make(chan api.RawItemR)
+	chout := make(chan api.RawItemR)
+	ch.AddStep(p.NewProcessor(chin, chout, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ch.Process(ctx)
+
+	go func() {
+		for _, item := range items {
+			chin <- item
+		}
+	}()
+
+	var res []api.RawItemR
+	for range items {
+		select {
+		case item := <-chout:
+			res = append(res, item)
+		case err := <-errch:
+			t.Fatal(err)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for item")
+		}
+	}
+	return res
+}
+
+func newItem(guid string) *api.RawItem {
+	item := api.NewRawItem(guid+".jpg", nil)
+	item.SetGuid(guid)
+	return item
+}
+
+func TestPerceptor(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "cache.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	inner := &countingPerceptor{version: "1"}
+	p := Wrap(inner, store)
+
+	run(t, p, newItem("a"), newItem("b"), api.NewRawItem("no_guid.jpg", nil))
+	if inner.calls.Load() != 3 || store.Len() != 2 {
+		t.Fatalf("expected 3 calls and 2 entries, got %d and %d", inner.calls.Load(), store.Len())
+	}
+
+	items := run(t, p, newItem("a"), newItem("b"))
+	if inner.calls.Load() != 3 {
+		t.Errorf("cached items should not be processed, got %d calls", inner.calls.Load())
+	}
+	for _, item := range items {
+		raw := item.(*api.RawItem)
+		if raw.GetSize() != (api.Size{W: 3, H: 2}) || raw.SetBy(api.FieldSize) != "counting" {
+			t.Errorf("size is not restored from cache: %v", raw.GetSize())
+		}
+		if tags := raw.GetMetadata().Tags; len(tags) != 1 || tags[0] != "counted" {
+			t.Errorf("metadata is not restored from cache: %v", tags)
+		}
+		if r, ok := raw.GetResults().Get("counting"); !ok || r.Tags[0] != "landscape" {
+			t.Error("result is not restored from cache")
+		}
+	}
+
+	inner.version = "2"
+	run(t, p, newItem("a"))
+	if inner.calls.Load() != 4 {
+		t.Errorf("new version should process item again, got %d calls", inner.calls.Load())
+	}
+}
+
+func TestPerceptorOwnMetadata(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "cache.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	var guids atomic.Int32
+	p := Wrap(&countingPerceptor{version: "1"}, store)
+	p.Guid = api.GuidFunc(func(item api.RawItemR) (string, error) {
+		guids.Add(1)
+		return item.GetGuid(), nil
+	})
+
+	// tag set by earlier perceptor isn't a part of cached output
+	item := newItem("a")
+	item.AddTags("earlier")
+	run(t, p, item)
+	if guids.Load() != 1 {
+		t.Errorf("guid should be computed once per item, got %d", guids.Load())
+	}
+
+	e, ok := store.Get(Key{Guid: "a", Perceptor: "counting", Version: "1"})
+	if !ok {
+		t.Fatal("output is not stored")
+	}
+	if tags := e.Fields.Metadata.Tags; len(tags) != 1 || tags[0] != "counted" {
+		t.Errorf("only metadata of the perceptor should be cached, got %v", tags)
+	}
+
+	items := run(t, p, newItem("a"))
+	if tags := items[0].(*api.RawItem).GetMetadata().Tags; len(tags) != 1 || tags[0] != "counted" {
+		t.Errorf("unexpected restored tags %v", tags)
+	}
+}
+
+func TestPerceptorStoreFailure(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "cache.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	inner := &countingPerceptor{version: "1"}
+	items := run(t, Wrap(inner, store), newItem("a"))
+	if len(items) != 1 || inner.calls.Load() != 1 {
+		t.Error("item should pass when its output can't be stored")
+	}
+}
+
+func TestPerceptorConfigured(t *testing.T) {
+	p := Wrap(exif_size.New(), nil)
+
+	res, err := api.Configured([]api.ExifPerceptor{p}, api.ConfigSet{exif_size.Name: {"snap_ratio": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone := res[0].(*Perceptor)
+	if clone.Unwrap().(*exif_size.Perceptor).Classifier == nil || clone.Version() == p.Version() {
+		t.Error("clone should be configured together with wrapped perceptor")
+	}
+	if p.Unwrap().(*exif_size.Perceptor).Classifier != nil || p.config != "" {
+		t.Error("configuring clone shouldn't change the perceptor")
+	}
+}
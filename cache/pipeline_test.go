@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/exiftool"
+	"github.com/dukobpa3/perceplib/perceptors/exif_geo"
+)
+
+// fakeExiftool emulates stay_open protocol of exiftool, every file gets gps position and image hash
+const fakeExiftool = `#!/bin/sh
+files=""
+while IFS= read -r line; do
+	case "$line" in
+	-execute*)
+		n=0
+		for f in $files; do
+			printf '======== %s\nFileName : %s\nGPSLatitude : 43.3\nGPSLongitude : 5.4\nImageDataHash : %s\n' "$f" "$(basename "$f")" "$(basename "$f")"
+			n=$((n+1))
+		done
+		printf '    %d image files read\n' $n
+		echo "{ready1854673209}"
+		echo "{ready1854673209}" >&2
+		files="" ;;
+	false) exit 0 ;;
+	-*) ;;
+	*) files="$files $line" ;;
+	esac
+done
+`
+
+func withFakeExiftool(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake exiftool is a shell script")
+	}
+
+	path := filepath.Join(t.TempDir(), "exiftool")
+	if err := os.WriteFile(path, []byte(fakeExiftool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	exec, arg1, config := exiftool.Exec, exiftool.Arg1, exiftool.Config
+	exiftool.Exec, exiftool.Arg1, exiftool.Config = path, "", ""
+	t.Cleanup(func() {
+		exiftool.Exec, exiftool.Arg1, exiftool.Config = exec, arg1, config
+	})
+}
+
+func TestPerceptorPipeline(t *testing.T) {
+	withFakeExiftool(t)
+
+	store, err := Open(filepath.Join(t.TempDir(), "cache.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// run returns locations of sunk items by file name
+	run := func() map[string]api.Location {
+		var mu sync.Mutex
+		res := make(map[string]api.Location)
+		sink := api.SinkFunc(func(item api.RawItemR) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if mp, ok := api.MetadataOf(item); ok {
+				res[item.GetExif("FileName")], _ = mp.GetLocation()
+			}
+			return nil
+		})
+
+		files := api.FileList{"/photos/a.jpg", "/photos/b.jpg"}
+		p := api.NewPipeline(files, sink, nil, Wrap(exif_geo.New(), store))
+		if err := p.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	first := run()
+	if len(first) != 2 || first["a.jpg"].Latitude != 43.3 {
+		t.Fatalf("unexpected locations %v", first)
+	}
+	e, ok := store.Get(Key{Guid: "hash:a.jpg", Perceptor: exif_geo.Name, Version: exif_geo.Version})
+	if !ok || e.Fields.Location == nil || e.Fields.Location.Longitude != 5.4 {
+		t.Fatalf("location of pipeline item is not stored: %+v", e)
+	}
+
+	second := run()
+	if store.Stale() != 0 {
+		t.Error("cached items should not be processed again")
+	}
+	for name, loc := range first {
+		if second[name] != loc {
+			t.Errorf("%s: cached location %v differs from %v", name, second[name], loc)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Package cache keeps results of perceptors between runs, so unchanged items are not processed again.
+*/
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+var ErrClosed = errors.New("cache: store is closed")
+
+// Key identifies result of given perceptor version for item with given guid
+type Key struct {
+	Guid      string `json:"guid"`
+	Perceptor string `json:"perceptor"`
+	Version   string `json:"version,omitempty"`
+}
+
+// Fields are item fields set by perceptor
+type Fields struct {
+	Guid     string        `json:"guid,omitempty"`
+	Date     *time.Time    `json:"date,omitempty"`
+	Size     *api.Size     `json:"size,omitempty"`
+	Ratio    *api.Size     `json:"ratio,omitempty"`
+	Location *api.Location `json:"location,omitempty"`
+	Metadata *api.Metadata `json:"metadata,omitempty"`
+}
+
+// Entry is a cached output of perceptor for single item
+type Entry struct {
+	Key
+	Result  *api.Result `json:"result,omitempty"`
+	Fields  Fields      `json:"fields"`
+	Created time.Time   `json:"created"`
+}
+
+// record is a line of store file, deleted records remove earlier entries with the same key
+type record struct {
+	Entry
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Store is a file backed cache, entries are appended to the file as json lines
+// and loaded into memory on open. Store is safe for concurrent use by multiple goroutines.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	w       *bufio.Writer
+	entries map[Key]*Entry
+	stale   int // lines which don't hold actual entries, see Compact
+}
+
+// Open loads store from file, file is created if it doesn't exist.
+// Malformed lines are skipped, unfinished last line written during crash is cut off,
+// so the next entry starts at the new line
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, entries: make(map[Key]*Entry)}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	var complete int64 // size of the file up to the end of the last full line
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		complete += int64(len(line))
+		s.load(line)
+	}
+
+	info, err := f.Stat()
+	if err == nil && info.Size() > complete {
+		err = f.Truncate(complete)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s.file, s.w = f, bufio.NewWriter(f)
+	return s, nil
+}
+
+func (s *Store) load(line []byte) {
+	var r record
+	if err := json.Unmarshal(line, &r); err != nil {
+		s.stale++
+		return
+	}
+	if _, ok := s.entries[r.Key]; ok {
+		s.stale++
+	}
+	if r.Deleted {
+		delete(s.entries, r.Key)
+		s.stale++
+		return
+	}
+	e := r.Entry
+	s.entries[r.Key] = &e
+}
+
+func (s *Store) append(r record) error {
+	if s.file == nil {
+		return ErrClosed
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// Get returns entry with given key
+func (s *Store) Get(key Key) (*Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.entries[key]
+	return e, ok
+}
+
+// Put stores entry replacing previous one with the same key
+func (s *Store) Put(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Created.IsZero() {
+		e.Created = time.Now()
+	}
+	if err := s.append(record{Entry: *e}); err != nil {
+		return err
+	}
+	if _, ok := s.entries[e.Key]; ok {
+		s.stale++
+	}
+	s.entries[e.Key] = e
+	return nil
+}
+
+// Len returns count of entries
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// Invalidate deletes all entries matching the filter and returns their count
+func (s *Store) Invalidate(match func(Key) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for key := range s.entries {
+		if !match(key) {
+			continue
+		}
+		if err := s.append(record{Entry: Entry{Key: key}, Deleted: true}); err != nil {
+			return n, err
+		}
+		delete(s.entries, key)
+		s.stale += 2
+		n++
+	}
+	return n, nil
+}
+
+// Delete deletes single entry
+func (s *Store) Delete(key Key) error {
+	_, err := s.Invalidate(func(k Key) bool { return k == key })
+	return err
+}
+
+// InvalidateGuid deletes results of all perceptors for the item
+func (s *Store) InvalidateGuid(guid string) (int, error) {
+	return s.Invalidate(func(k Key) bool { return k.Guid == guid })
+}
+
+// InvalidatePerceptor deletes results of all versions of the perceptor
+func (s *Store) InvalidatePerceptor(perceptor string) (int, error) {
+	return s.Invalidate(func(k Key) bool { return k.Perceptor == perceptor })
+}
+
+// InvalidateOutdated deletes results of the perceptor made by versions other than given one
+func (s *Store) InvalidateOutdated(perceptor, version string) (int, error) {
+	return s.Invalidate(func(k Key) bool { return k.Perceptor == perceptor && k.Version != version })
+}
+
+// Clear deletes all entries
+func (s *Store) Clear() (int, error) {
+	return s.Invalidate(func(Key) bool { return true })
+}
+
+// Compact rewrites file with actual entries only
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return ErrClosed
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range s.entries {
+		if err = enc.Encode(record{Entry: *e}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	s.file.Close()
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.w = bufio.NewWriter(s.file)
+	s.stale = 0
+	return nil
+}
+
+// Stale returns count of file lines which would be dropped by Compact
+func (s *Store) Stale() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stale
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	return err
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := api.Size{W: 3, H: 2}
+	keys := []Key{
+		{Guid: "a", Perceptor: "size", Version: "1"},
+		{Guid: "a", Perceptor: "size", Version: "2"},
+		{Guid: "b", Perceptor: "size", Version: "2"},
+		{Guid: "a", Perceptor: "date", Version: "1"},
+	}
+	for _, key := range keys {
+		if err := s.Put(&Entry{Key: key, Fields: Fields{Size: &size}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := s.InvalidateOutdated("size", "2"); err != nil || n != 1 {
+		t.Errorf("expected 1 outdated entry, got %d, %v", n, err)
+	}
+	if err := s.Delete(keys[3]); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// crash in the middle of the line
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"guid":"c","perc`)
+	f.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.Len() != 2 {
+		t.Errorf("expected 2 entries after reopen, got %d", s.Len())
+	}
+	if e, ok := s.Get(keys[1]); !ok || e.Fields.Size == nil || *e.Fields.Size != size {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if _, ok := s.Get(keys[0]); ok {
+		t.Error("outdated entry should be invalidated")
+	}
+
+	// entry put after crash shouldn't be glued to the unfinished line
+	added := Key{Guid: "d", Perceptor: "size", Version: "2"}
+	if err := s.Put(&Entry{Key: added, Fields: Fields{Size: &size}}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, ok := s.Get(added); !ok || s.Len() != 3 {
+		t.Errorf("entry put after crash should be kept, got %d entries", s.Len())
+	}
+	if n, err := s.InvalidateGuid("d"); err != nil || n != 1 {
+		t.Errorf("expected 1 entry of guid, got %d, %v", n, err)
+	}
+
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Stale() != 0 {
+		t.Errorf("expected no stale lines after compact, got %d", s.Stale())
+	}
+	if n, err := s.InvalidateGuid("b"); err != nil || n != 1 {
+		t.Errorf("expected 1 entry of guid, got %d, %v", n, err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, ok := s.Get(keys[1]); !ok || s.Len() != 1 {
+		t.Errorf("expected single entry after compact and reopen, got %d", s.Len())
+	}
+}
@@ -27,8 +27,19 @@ type chain struct {
 	actors []Processor
 }
 
+// setErrorChannel also redirects errors of added actors, so chain could be nested into another chain
 func (ch *chain) setErrorChannel(errch chan<- error) {
 	ch.errch = errch
+	for _, a := range ch.actors {
+		a.setErrorChannel(errch)
+	}
+}
+
+// setErrorHook sets hook of actors added so far
+func (ch *chain) setErrorHook(hook ErrorHook) {
+	for _, a := range ch.actors {
+		WithErrorHook(a, hook)
+	}
 }
 
 func (ch *chain) AddStep(a Processor) {
@@ -50,9 +61,18 @@ func (ch *chain) Process(parentCtx context.Context) {
 		}(actor)
 	}
 
-	<-ctx.Done()
-	wg.Wait()
+	// actors return on cancel or when their input is closed and drained
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 
+	select {
+	case <-ctx.Done():
+		<-done
+	case <-done:
+	}
 }
 
 func NewChainProcessor(errch chan error) *chain {
@@ -129,6 +129,25 @@ func TestChainProcessor(t *testing.T) {
 		wg.Wait()
 	})
 
+	t.Run("return when all steps are done", func(t *testing.T) {
+		chain := NewChainProcessor(make(chan error, 1))
+		for i := 0; i < 2; i++ {
+			chain.AddStep(&MockProcessor{processFunc: func(context.Context) {}})
+		}
+
+		done := make(chan struct{})
+		go func() {
+			chain.Process(context.Background())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("chain should return once its steps returned")
+		}
+	})
+
 	t.Run("add steps", func(t *testing.T) {
 		errch := make(chan error, 1)
 		ch := NewChainProcessor(errch)
@@ -153,6 +172,20 @@ func TestChainProcessor(t *testing.T) {
 	})
 }
 
+func TestNestedChain(t *testing.T) {
+	inner := NewChainProcessor(nil)
+	proc := &MockProcessor{}
+	inner.AddStep(proc)
+
+	errch := make(chan error)
+	outer := NewChainProcessor(errch)
+	outer.AddStep(inner)
+
+	if proc.errch != errch {
+		t.Error("error channel of nested chain should be passed to its actors")
+	}
+}
+
 func TestErrSkippedItem(t *testing.T) {
 	err := ErrSkippedItem
 	if err.Error() == "" {
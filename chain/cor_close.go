@@ -0,0 +1,28 @@
+package chain
+
+import (
+	"context"
+)
+
+type closeRunner struct {
+	Processor
+	close func()
+}
+
+func (c *closeRunner) setErrorHook(hook ErrorHook) {
+	WithErrorHook(c.Processor, hook)
+}
+
+func (c *closeRunner) Process(ctx context.Context) {
+	defer c.close()
+	c.Processor.Process(ctx)
+}
+
+// WithClose calls closeFn once processor returns, e.g. to close its output channels.
+// Runners return when their input is closed, so closing input of the first step drains the whole chain
+func WithClose(processor Processor, closeFn func()) Processor {
+	return &closeRunner{
+		Processor: processor,
+		close:     closeFn,
+	}
+}
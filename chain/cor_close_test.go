@@ -0,0 +1,45 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithClose(t *testing.T) {
+	chin := make(chan int)
+	mid := make(chan int)
+	chout := make(chan int)
+
+	double := &mockDecorator[int, int]{decorateFunc: func(i int) (int, error) { return i * 2, nil }}
+
+	ch := NewChainProcessor(make(chan error, 1))
+	ch.AddStep(WithClose(NewDecorator(chin, mid, double), func() { close(mid) }))
+	ch.AddStep(WithClose(NewDecorator(mid, chout, double), func() { close(chout) }))
+
+	done := make(chan struct{})
+	go func() {
+		ch.Process(context.Background())
+		close(done)
+	}()
+
+	go func() {
+		chin <- 1
+		chin <- 2
+		close(chin)
+	}()
+
+	var got []int
+	for v := range chout {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 4 || got[1] != 8 {
+		t.Errorf("unexpected output %v", got)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("chain was not drained after input closed")
+	}
+}
@@ -14,12 +14,17 @@ type decoratorRunner[Ti any, To any] struct {
 	chin      <-chan Ti
 	chout     chan<- To
 	processor Decorator[Ti, To]
+	hook      ErrorHook
 }
 
 func (d *decoratorRunner[Ti, To]) setErrorChannel(cherr chan<- error) {
 	d.cherr = cherr
 }
 
+func (d *decoratorRunner[Ti, To]) setErrorHook(hook ErrorHook) {
+	d.hook = hook
+}
+
 func (d *decoratorRunner[Ti, To]) Process(parentCtx context.Context) {
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
@@ -37,9 +42,17 @@ func (d *decoratorRunner[Ti, To]) Process(parentCtx context.Context) {
 			}
 			res, err := d.processor.Decorate(input)
 			if err != nil {
+				if d.hook != nil {
+					d.hook(input, err)
+				}
 				d.cherr <- err
 			} else {
-				d.chout <- res
+				select {
+				case d.chout <- res:
+				case <-ctx.Done():
+					d.processor.Stop()
+					return
+				}
 			}
 		}
 	}
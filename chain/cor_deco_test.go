@@ -196,4 +196,33 @@ func TestDecorator(t *testing.T) {
 			t.Error("decorator was not stopped after input channel closure")
 		}
 	})
+
+	t.Run("stop on context cancel while sending", func(t *testing.T) {
+		chin := make(chan int)
+		mock := &mockDecorator[int, string]{
+			decorateFunc: func(i int) (string, error) {
+				return string(rune(i + 65)), nil
+			},
+		}
+
+		// nobody reads output, e.g. next step is already stopped
+		decorator := NewDecorator(chin, make(chan string), mock)
+		decorator.setErrorChannel(make(chan error, 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			decorator.Process(ctx)
+			close(done)
+		}()
+
+		chin <- 1
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("decorator blocked on output after context cancellation")
+		}
+	})
 }
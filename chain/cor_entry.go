@@ -42,7 +42,12 @@ func (d *entryRunner[Ti, To]) Process(parentCtx context.Context) {
 			if err != nil {
 				d.cherr <- err
 			} else {
-				d.chout <- res
+				select {
+				case d.chout <- res:
+				case <-ctx.Done():
+					d.processor.Stop()
+					return
+				}
 			}
 		}
 	}
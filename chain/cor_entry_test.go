@@ -162,4 +162,35 @@ func TestEntryPoint(t *testing.T) {
 			t.Error("entry point was not stopped after context cancellation")
 		}
 	})
+
+	t.Run("stop on context cancel while sending", func(t *testing.T) {
+		mock := &mockEntryPoint[int, string]{
+			startFunc: func(ch chan<- int, ctx context.Context) {
+				ch <- 1
+			},
+			decorateFunc: func(i int) (string, error) {
+				return string(rune(i + 65)), nil
+			},
+		}
+
+		// nobody reads output, e.g. next step is already stopped
+		entry := NewEntryPoint(make(chan string), mock)
+		entry.setErrorChannel(make(chan error, 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			entry.Process(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("entry point blocked on output after context cancellation")
+		}
+	})
 }
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"context"
+)
+
+type Expander[Ti any, To any] interface {
+	worker
+	// Expand turns single input into any number of outputs, each goes to output as separate value
+	Expand(Ti) ([]To, error)
+}
+
+type expandRunner[Ti any, To any] struct {
+	cherr     chan<- error
+	chin      <-chan Ti
+	chout     chan<- To
+	processor Expander[Ti, To]
+	hook      ErrorHook
+}
+
+func (e *expandRunner[Ti, To]) setErrorChannel(cherr chan<- error) {
+	e.cherr = cherr
+}
+
+func (e *expandRunner[Ti, To]) setErrorHook(hook ErrorHook) {
+	e.hook = hook
+}
+
+func (e *expandRunner[Ti, To]) Process(parentCtx context.Context) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.processor.Stop()
+			return
+
+		case input, ok := <-e.chin:
+			if !ok {
+				e.processor.Stop()
+				return
+			}
+			res, err := e.processor.Expand(input)
+			if err != nil {
+				if e.hook != nil {
+					e.hook(input, err)
+				}
+				e.cherr <- err
+				continue
+			}
+			for _, out := range res {
+				select {
+				case e.chout <- out:
+				case <-ctx.Done():
+					e.processor.Stop()
+					return
+				}
+			}
+		}
+	}
+}
+
+// NewExpander puts every value the processor makes of input to output channel, e.g. items of groups.
+// Input without values is dropped silently
+func NewExpander[Ti any, To any](chin <-chan Ti, chout chan<- To, processor Expander[Ti, To]) Processor {
+	return &expandRunner[Ti, To]{
+		chin:      chin,
+		chout:     chout,
+		processor: processor,
+	}
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockExpander[Ti any, To any] struct {
+	expandFunc func(Ti) ([]To, error)
+	stopped    bool
+	mu         sync.Mutex
+}
+
+func (m *mockExpander[Ti, To]) Expand(input Ti) ([]To, error) {
+	return m.expandFunc(input)
+}
+
+func (m *mockExpander[Ti, To]) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
+
+func TestExpander(t *testing.T) {
+	t.Run("emits every value", func(t *testing.T) {
+		chin := make(chan []int)
+		chout := make(chan int)
+		cherr := make(chan error, 1)
+
+		mock := &mockExpander[[]int, int]{expandFunc: func(group []int) ([]int, error) {
+			return group, nil
+		}}
+
+		expander := WithClose(NewExpander(chin, chout, mock), func() { close(chout) })
+		expander.setErrorChannel(cherr)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go expander.Process(ctx)
+
+		go func() {
+			chin <- []int{1, 2}
+			chin <- nil
+			chin <- []int{3}
+			close(chin)
+		}()
+
+		var got []int
+		for v := range chout {
+			got = append(got, v)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("unexpected values %v", got)
+		}
+		if !mock.stopped {
+			t.Error("expander was not stopped after input channel closure")
+		}
+	})
+
+	t.Run("error handling", func(t *testing.T) {
+		chin := make(chan int)
+		chout := make(chan int)
+		cherr := make(chan error, 1)
+
+		expectedErr := errors.New("test error")
+		mock := &mockExpander[int, int]{expandFunc: func(i int) ([]int, error) {
+			if i == 1 {
+				return nil, expectedErr
+			}
+			return []int{i, i}, nil
+		}}
+
+		var failed []any
+		expander := WithErrorHook(NewExpander(chin, chout, mock), func(input any, err error) {
+			failed = append(failed, input)
+		})
+		expander.setErrorChannel(cherr)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			expander.Process(ctx)
+		}()
+
+		go func() {
+			chin <- 1
+			close(chin)
+		}()
+
+		select {
+		case err := <-cherr:
+			if err != expectedErr {
+				t.Errorf("expected error %v, got %v", expectedErr, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+		<-done
+
+		if !slices.Equal(failed, []any{1}) {
+			t.Errorf("hook should get failed input, got %v", failed)
+		}
+	})
+
+	t.Run("stop on context cancel while sending", func(t *testing.T) {
+		chin := make(chan int, 1)
+		chout := make(chan int)
+		cherr := make(chan error, 1)
+
+		mock := &mockExpander[int, int]{expandFunc: func(i int) ([]int, error) {
+			return []int{i, i}, nil
+		}}
+
+		expander := NewExpander(chin, chout, mock)
+		expander.setErrorChannel(cherr)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			expander.Process(ctx)
+		}()
+
+		chin <- 1
+		<-chout
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expander didn't return after context cancellation")
+		}
+		if !mock.stopped {
+			t.Error("expander was not stopped after context cancellation")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package chain
+
+import (
+	"context"
+)
+
+type Grouper[Ti any, To any] interface {
+	worker
+	// Group takes all items collected from input channel at once
+	// and splits them into groups, each group goes to output as separate value
+	Group([]Ti) ([]To, error)
+}
+
+type groupRunner[Ti any, To any] struct {
+	cherr     chan<- error
+	chin      <-chan Ti
+	chout     chan<- To
+	processor Grouper[Ti, To]
+}
+
+func (g *groupRunner[Ti, To]) setErrorChannel(cherr chan<- error) {
+	g.cherr = cherr
+}
+
+func (g *groupRunner[Ti, To]) Process(parentCtx context.Context) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	var items []Ti
+
+	for {
+		select {
+		case <-ctx.Done():
+			// cancelled before input is complete, groups would be partial
+			g.processor.Stop()
+			return
+
+		case input, ok := <-g.chin:
+			if ok {
+				items = append(items, input)
+				continue
+			}
+
+			g.flush(ctx, items)
+			g.processor.Stop()
+			return
+		}
+	}
+}
+
+func (g *groupRunner[Ti, To]) flush(ctx context.Context, items []Ti) {
+	if len(items) == 0 {
+		return
+	}
+
+	groups, err := g.processor.Group(items)
+	if err != nil {
+		g.cherr <- err
+		return
+	}
+
+	for _, group := range groups {
+		select {
+		case <-ctx.Done():
+			return
+		case g.chout <- group:
+		}
+	}
+}
+
+// NewGroupCollector collects every item from input channel until it is closed,
+// then passes them to the processor and puts resulting groups to output channel.
+func NewGroupCollector[Ti any, To any](chin <-chan Ti, chout chan<- To, processor Grouper[Ti, To]) Processor {
+	return &groupRunner[Ti, To]{
+		chin:      chin,
+		chout:     chout,
+		processor: processor,
+	}
+}
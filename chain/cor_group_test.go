@@ -0,0 +1,158 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockGrouper[Ti any, To any] struct {
+	groupFunc func([]Ti) ([]To, error)
+	stopped   bool
+	mu        sync.Mutex
+}
+
+func (m *mockGrouper[Ti, To]) Group(items []Ti) ([]To, error) {
+	return m.groupFunc(items)
+}
+
+func (m *mockGrouper[Ti, To]) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
+
+func TestGroupCollector(t *testing.T) {
+	t.Run("groups after input closed", func(t *testing.T) {
+		chin := make(chan int)
+		chout := make(chan []int)
+		cherr := make(chan error, 1)
+
+		mock := &mockGrouper[int, []int]{
+			groupFunc: func(items []int) ([][]int, error) {
+				var even, odd []int
+				for _, i := range items {
+					if i%2 == 0 {
+						even = append(even, i)
+					} else {
+						odd = append(odd, i)
+					}
+				}
+				return [][]int{even, odd}, nil
+			},
+		}
+
+		collector := NewGroupCollector(chin, chout, mock)
+		collector.setErrorChannel(cherr)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.Process(ctx)
+		}()
+
+		go func() {
+			for i := 0; i < 5; i++ {
+				chin <- i
+			}
+			close(chin)
+		}()
+
+		var groups [][]int
+		for i := 0; i < 2; i++ {
+			select {
+			case g := <-chout:
+				groups = append(groups, g)
+			case err := <-cherr:
+				t.Fatalf("unexpected error: %v", err)
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for group")
+			}
+		}
+
+		wg.Wait()
+
+		if len(groups[0]) != 3 || len(groups[1]) != 2 {
+			t.Errorf("unexpected groups: %v", groups)
+		}
+		if !mock.stopped {
+			t.Error("grouper was not stopped after input channel closure")
+		}
+	})
+
+	t.Run("error handling", func(t *testing.T) {
+		chin := make(chan int)
+		chout := make(chan []int)
+		cherr := make(chan error, 1)
+
+		expectedErr := errors.New("test error")
+		mock := &mockGrouper[int, []int]{
+			groupFunc: func(items []int) ([][]int, error) {
+				return nil, expectedErr
+			},
+		}
+
+		collector := NewGroupCollector(chin, chout, mock)
+		collector.setErrorChannel(cherr)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go collector.Process(ctx)
+
+		go func() {
+			chin <- 1
+			close(chin)
+		}()
+
+		select {
+		case err := <-cherr:
+			if err != expectedErr {
+				t.Errorf("expected error %v, got %v", expectedErr, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+	})
+
+	t.Run("stop on context cancel", func(t *testing.T) {
+		chin := make(chan int)
+		chout := make(chan []int)
+		called := false
+
+		mock := &mockGrouper[int, []int]{
+			groupFunc: func(items []int) ([][]int, error) {
+				called = true
+				return [][]int{items}, nil
+			},
+		}
+
+		collector := NewGroupCollector(chin, chout, mock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.Process(ctx)
+		}()
+
+		chin <- 1
+		cancel()
+		wg.Wait()
+
+		if called {
+			t.Error("partial group should not be processed after cancellation")
+		}
+		if !mock.stopped {
+			t.Error("grouper was not stopped after context cancellation")
+		}
+	})
+}
@@ -0,0 +1,18 @@
+package chain
+
+// ErrorHook is called with input which the runner failed to process, before the error is reported
+type ErrorHook func(input any, err error)
+
+type hookable interface {
+	setErrorHook(hook ErrorHook)
+}
+
+// WithErrorHook lets the caller learn which inputs of the processor failed, e.g. to forget state kept for them.
+// Hook is called by decorators, switches and expanders, also nested into chains or wrapped by WithClose,
+// other runners report errors of whole sets and don't call it. Processor is returned as is
+func WithErrorHook(processor Processor, hook ErrorHook) Processor {
+	if h, ok := processor.(hookable); ok {
+		h.setErrorHook(hook)
+	}
+	return processor
+}
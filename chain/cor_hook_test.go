@@ -0,0 +1,58 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithErrorHook(t *testing.T) {
+	chin := make(chan int)
+	chout := make(chan int)
+	errch := make(chan error, 1)
+	errOdd := errors.New("odd")
+
+	odd := &mockDecorator[int, int]{decorateFunc: func(i int) (int, error) {
+		if i%2 == 1 {
+			return 0, errOdd
+		}
+		return i, nil
+	}}
+
+	var failed []any
+	inner := NewChainProcessor(nil)
+	inner.AddStep(WithClose(NewDecorator(chin, chout, odd), func() { close(chout) }))
+
+	ch := NewChainProcessor(errch)
+	ch.AddStep(WithErrorHook(inner, func(input any, err error) {
+		if errors.Is(err, errOdd) {
+			failed = append(failed, input)
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ch.Process(ctx)
+
+	go func() {
+		chin <- 1
+		chin <- 2
+		close(chin)
+	}()
+
+	select {
+	case err := <-errch:
+		if err != errOdd {
+			t.Errorf("error should be reported as is, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for error")
+	}
+	for range chout {
+	}
+
+	if len(failed) != 1 || failed[0] != 1 {
+		t.Errorf("hook should get failed input, got %v", failed)
+	}
+}
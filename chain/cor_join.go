@@ -0,0 +1,198 @@
+package chain
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultJoinLimit is a limit of items NewJoin waits for at the same time
+const DefaultJoinLimit = 1024
+
+// ErrJoinEvicted is reported when join forgets waiting item to stay within its limit,
+// the item is lost even if a lagging branch delivers it later
+var ErrJoinEvicted = errors.New("join: item evicted before it came from every input")
+
+type joinRunner[T comparable] struct {
+	cherr chan<- error
+	chin  []<-chan T
+	chout chan<- T
+	limit int
+}
+
+// pending is an item which hasn't come from every input yet
+type pending[T comparable] struct {
+	item    T
+	arrived int
+}
+
+func (j *joinRunner[T]) setErrorChannel(cherr chan<- error) {
+	j.cherr = cherr
+}
+
+func (j *joinRunner[T]) Process(parentCtx context.Context) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	merged := make(chan T)
+	wg := &sync.WaitGroup{}
+	for _, ch := range j.chin {
+		wg.Add(1)
+		go func(ch <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case input, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case merged <- input:
+					}
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	// items in order of first arrival, the oldest one is evicted when limit is exceeded.
+	// Evicted items are remembered for a while, so late deliveries don't wait as new items
+	waiting := newPendingList[T]()
+	evicted := newPendingList[T]()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case input, ok := <-merged:
+			if !ok {
+				return
+			}
+			if p, ok := evicted.get(input); ok {
+				if p.arrived++; p.arrived == len(j.chin) {
+					evicted.remove(input)
+				}
+				continue
+			}
+
+			p := waiting.add(input)
+			p.arrived++
+			if p.arrived < len(j.chin) {
+				if waiting.len() > j.limit {
+					// dropped by some branch or the branch lags too much, it can't be told apart
+					oldest := waiting.pop()
+					evicted.push(oldest)
+					if evicted.len() > j.limit {
+						evicted.pop()
+					}
+					if !j.report(ctx, ErrJoinEvicted) {
+						return
+					}
+				}
+				continue
+			}
+			waiting.remove(input)
+
+			select {
+			case <-ctx.Done():
+				return
+			case j.chout <- input:
+			}
+		}
+	}
+}
+
+// pendingList keeps pending items in order of their first arrival
+type pendingList[T comparable] struct {
+	order *list.List
+	items map[T]*list.Element
+}
+
+func newPendingList[T comparable]() *pendingList[T] {
+	return &pendingList[T]{order: list.New(), items: make(map[T]*list.Element)}
+}
+
+func (l *pendingList[T]) len() int {
+	return l.order.Len()
+}
+
+func (l *pendingList[T]) get(item T) (*pending[T], bool) {
+	e, ok := l.items[item]
+	if !ok {
+		return nil, false
+	}
+	return e.Value.(*pending[T]), true
+}
+
+// add returns pending item, new one is added to the end
+func (l *pendingList[T]) add(item T) *pending[T] {
+	if p, ok := l.get(item); ok {
+		return p
+	}
+	p := &pending[T]{item: item}
+	l.push(p)
+	return p
+}
+
+func (l *pendingList[T]) push(p *pending[T]) {
+	l.items[p.item] = l.order.PushBack(p)
+}
+
+// pop removes and returns the oldest item
+func (l *pendingList[T]) pop() *pending[T] {
+	p := l.order.Remove(l.order.Front()).(*pending[T])
+	delete(l.items, p.item)
+	return p
+}
+
+func (l *pendingList[T]) remove(item T) {
+	if e, ok := l.items[item]; ok {
+		l.order.Remove(e)
+		delete(l.items, item)
+	}
+}
+
+// report sends error unless join runs without error channel, false if context is done
+func (j *joinRunner[T]) report(ctx context.Context, err error) bool {
+	if j.cherr == nil {
+		return true
+	}
+	select {
+	case j.cherr <- err:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// NewJoin waits until the same item comes from every input channel and only then puts it to output.
+// Used to merge parallel branches which process shared items, so items should be comparable (e.g. pointers).
+// Items dropped by any branch never reach the output, see NewJoinLimit.
+func NewJoin[T comparable](chin []<-chan T, chout chan<- T) Processor {
+	return NewJoinLimit(chin, chout, DefaultJoinLimit)
+}
+
+// NewJoinLimit is NewJoin which waits for at most limit items at the same time.
+// Branches can't tell about dropped items, so the oldest waiting item is forgotten when limit is exceeded
+// and ErrJoinEvicted is reported, it can't be joined anymore. Late deliveries of evicted items are discarded
+// as long as no more than limit items were evicted after them.
+// Limit should be greater than amount of items branches hold at once, so branches collecting all items don't fit
+func NewJoinLimit[T comparable](chin []<-chan T, chout chan<- T, limit int) Processor {
+	if limit <= 0 {
+		limit = DefaultJoinLimit
+	}
+	return &joinRunner[T]{
+		chin:  chin,
+		chout: chout,
+		limit: limit,
+	}
+}
@@ -0,0 +1,169 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJoin(t *testing.T) {
+	t.Run("emits after all branches", func(t *testing.T) {
+		chin1 := make(chan *int)
+		chin2 := make(chan *int)
+		chout := make(chan *int)
+
+		join := NewJoin([]<-chan *int{chin1, chin2}, chout)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			join.Process(ctx)
+		}()
+
+		a, b := new(int), new(int)
+		chin1 <- a
+		chin1 <- b
+
+		select {
+		case <-chout:
+			t.Fatal("item emitted before it came from every branch")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		chin2 <- b
+		select {
+		case got := <-chout:
+			if got != b {
+				t.Error("unexpected item")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for joined item")
+		}
+
+		chin2 <- a
+		select {
+		case got := <-chout:
+			if got != a {
+				t.Error("unexpected item")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for joined item")
+		}
+
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("forgets dropped items", func(t *testing.T) {
+		chin1 := make(chan *int)
+		chin2 := make(chan *int)
+		chout := make(chan *int)
+
+		join := NewJoinLimit([]<-chan *int{chin1, chin2}, chout, 2)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go join.Process(ctx)
+
+		// the second branch dropped the first item, it's forgotten when the third one comes
+		items := []*int{new(int), new(int), new(int)}
+		for _, item := range items {
+			chin1 <- item
+		}
+		for _, item := range items[1:] {
+			chin2 <- item
+			select {
+			case got := <-chout:
+				if got != item {
+					t.Error("unexpected item")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for joined item")
+			}
+		}
+
+		chin2 <- items[0]
+		select {
+		case <-chout:
+			t.Fatal("forgotten item should not be emitted")
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("reports items evicted from lagging branch", func(t *testing.T) {
+		chin1 := make(chan *int)
+		chin2 := make(chan *int)
+		chout := make(chan *int, 4)
+		errch := make(chan error, 4)
+
+		join := NewJoinLimit([]<-chan *int{chin1, chin2}, chout, 2)
+		join.setErrorChannel(errch)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go join.Process(ctx)
+
+		// the second branch lags by more than the limit, two oldest items are evicted
+		items := []*int{new(int), new(int), new(int), new(int)}
+		for _, item := range items {
+			chin1 <- item
+		}
+		for range 2 {
+			select {
+			case err := <-errch:
+				if !errors.Is(err, ErrJoinEvicted) {
+					t.Errorf("expected ErrJoinEvicted, got %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for eviction error")
+			}
+		}
+		for _, item := range items {
+			chin2 <- item
+		}
+		for _, item := range items[2:] {
+			select {
+			case got := <-chout:
+				if got != item {
+					t.Error("unexpected item")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for joined item")
+			}
+		}
+		select {
+		case <-chout:
+			t.Error("evicted item should not be emitted")
+		case err := <-errch:
+			t.Errorf("late evicted items should be discarded, got %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("stops when inputs closed", func(t *testing.T) {
+		chin1 := make(chan int)
+		chin2 := make(chan int)
+		join := NewJoin([]<-chan int{chin1, chin2}, make(chan int))
+
+		done := make(chan struct{})
+		go func() {
+			join.Process(context.Background())
+			close(done)
+		}()
+
+		close(chin1)
+		close(chin2)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("join did not stop after inputs closed")
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package chain
+
+import (
+	"context"
+)
+
+type Consumer[Ti any] interface {
+	worker
+	// Consume takes final result of the chain
+	Consume(Ti) error
+}
+
+type sinkRunner[Ti any] struct {
+	cherr     chan<- error
+	chin      <-chan Ti
+	processor Consumer[Ti]
+}
+
+func (s *sinkRunner[Ti]) setErrorChannel(cherr chan<- error) {
+	s.cherr = cherr
+}
+
+func (s *sinkRunner[Ti]) Process(parentCtx context.Context) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.processor.Stop()
+			return
+
+		case input, ok := <-s.chin:
+			if !ok {
+				s.processor.Stop()
+				return
+			}
+			if err := s.processor.Consume(input); err != nil {
+				s.cherr <- err
+			}
+		}
+	}
+}
+
+// NewSink finishes the chain, every item from input channel goes to the processor
+func NewSink[Ti any](chin <-chan Ti, processor Consumer[Ti]) Processor {
+	return &sinkRunner[Ti]{
+		chin:      chin,
+		processor: processor,
+	}
+}
@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockConsumer[Ti any] struct {
+	consumeFunc func(Ti) error
+	stopped     bool
+	mu          sync.Mutex
+}
+
+func (m *mockConsumer[Ti]) Consume(input Ti) error {
+	return m.consumeFunc(input)
+}
+
+func (m *mockConsumer[Ti]) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
+
+func TestSink(t *testing.T) {
+	t.Run("consume and errors", func(t *testing.T) {
+		chin := make(chan int)
+		cherr := make(chan error, 1)
+
+		expectedErr := errors.New("test error")
+		var consumed []int
+		mock := &mockConsumer[int]{
+			consumeFunc: func(i int) error {
+				if i == 2 {
+					return expectedErr
+				}
+				consumed = append(consumed, i)
+				return nil
+			},
+		}
+
+		sink := NewSink(chin, mock)
+		sink.setErrorChannel(cherr)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Process(context.Background())
+		}()
+
+		chin <- 1
+		chin <- 2
+
+		select {
+		case err := <-cherr:
+			if err != expectedErr {
+				t.Errorf("expected error %v, got %v", expectedErr, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+
+		close(chin)
+		wg.Wait()
+
+		if len(consumed) != 1 || consumed[0] != 1 {
+			t.Errorf("unexpected consumed items %v", consumed)
+		}
+		if !mock.stopped {
+			t.Error("sink was not stopped after input channel closure")
+		}
+	})
+
+	t.Run("stop on context cancel", func(t *testing.T) {
+		mock := &mockConsumer[int]{consumeFunc: func(int) error { return nil }}
+		sink := NewSink(make(chan int), mock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Process(ctx)
+		}()
+
+		cancel()
+		wg.Wait()
+
+		if !mock.stopped {
+			t.Error("sink was not stopped after context cancellation")
+		}
+	})
+}
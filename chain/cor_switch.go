@@ -19,12 +19,17 @@ type switchRunner[Ti any, To any] struct {
 	chin      <-chan Ti
 	chout     []chan<- To
 	processor Switcher[Ti, To]
+	hook      ErrorHook
 }
 
 func (s *switchRunner[Ti, To]) setErrorChannel(cherr chan<- error) {
 	s.cherr = cherr
 }
 
+func (s *switchRunner[Ti, To]) setErrorHook(hook ErrorHook) {
+	s.hook = hook
+}
+
 func (s *switchRunner[Ti, To]) Process(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -42,11 +47,20 @@ func (s *switchRunner[Ti, To]) Process(ctx context.Context) {
 			}
 			res, err := s.processor.Switch(input)
 			if err != nil {
+				if s.hook != nil {
+					s.hook(input, err)
+				}
 				s.cherr <- err
 			} else {
 				for i, o := range res {
-					if i < len(s.chout) {
-						s.chout[i] <- o
+					if i >= len(s.chout) {
+						continue
+					}
+					select {
+					case s.chout[i] <- o:
+					case <-ctx.Done():
+						s.processor.Stop()
+						return
 					}
 				}
 			}
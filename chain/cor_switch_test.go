@@ -169,4 +169,33 @@ func TestSwitch(t *testing.T) {
 			t.Error("switch was not stopped after context cancellation")
 		}
 	})
+
+	t.Run("stop on context cancel while sending", func(t *testing.T) {
+		chin := make(chan int)
+		mock := &mockSwitcher[int, string]{
+			switchFunc: func(i int) (map[int]string, error) {
+				return map[int]string{0: "test"}, nil
+			},
+		}
+
+		// nobody reads output, e.g. next step is already stopped
+		sw := NewSwitch(chin, []chan<- string{make(chan string)}, mock)
+		sw.setErrorChannel(make(chan error, 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			sw.Process(ctx)
+			close(done)
+		}()
+
+		chin <- 1
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("switch blocked on output after context cancellation")
+		}
+	})
 }
@@ -3,10 +3,13 @@ package exiftool
 import (
 	"bytes"
 	"errors"
+	"slices"
+	"strings"
 )
 
 // Unmarshal parses the standard, short or veryShort ExifTool output formats.
 // Loads tag names and values into a map.
+// Group names printed with -G option, like "[QuickTime] CreateDate", are joined as "QuickTime:CreateDate"
 func Unmarshal(data []byte, m map[string][]byte) error {
 	for len(data) > 0 {
 		i := bytes.IndexByte(data, '\n')
@@ -19,10 +22,66 @@ func Unmarshal(data []byte, m map[string][]byte) error {
 			return errors.New("exiftool: missing separator")
 		}
 
-		key := bytes.TrimSpace(data[:j])
+		key := groupKey(bytes.TrimSpace(data[:j]))
 		val := bytes.TrimSuffix(data[j+2:i], []byte("\r"))
-		m[string(key)] = val
+		m[key] = val
 		data = data[i+1:]
 	}
 	return nil
 }
+
+// groupKey converts "[Group] Tag" to "Group:Tag", other keys are returned as is
+func groupKey(key []byte) string {
+	if len(key) == 0 || key[0] != '[' {
+		return string(key)
+	}
+	end := bytes.IndexByte(key, ']')
+	if end < 2 {
+		return string(key)
+	}
+	tag := bytes.TrimSpace(key[end+1:])
+	if len(tag) == 0 {
+		return string(key)
+	}
+	return string(key[1:end]) + ":" + string(tag)
+}
+
+// GroupPriority orders groups printed with -G. When the same tag comes in several groups,
+// its bare name refers to the tag of the earliest group, see Index.
+// Groups which are not listed follow the listed ones in order of their names
+var GroupPriority = []string{"EXIF", "MakerNotes", "QuickTime", "Matroska", "XMP", "IPTC", "Composite", "File", "ExifTool"}
+
+// Index maps bare tag names of group qualified keys "Group:Tag" to the key of the group with the highest priority,
+// so they are found without scanning the map. Keys printed without group are not indexed, they are found by their names
+func Index(m map[string][]byte) map[string]string {
+	index := make(map[string]string)
+	for key := range m {
+		group, tag, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		if other, ok := index[tag]; ok {
+			otherGroup, _, _ := strings.Cut(other, ":")
+			if !groupBefore(group, otherGroup) {
+				continue
+			}
+		}
+		index[tag] = key
+	}
+	return index
+}
+
+func groupBefore(a, b string) bool {
+	ra, rb := groupRank(a), groupRank(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+func groupRank(group string) int {
+	if i := slices.Index(GroupPriority, group); i >= 0 {
+		return i
+	}
+	return len(GroupPriority)
+}
@@ -0,0 +1,71 @@
+package exiftool
+
+import (
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	data := []byte("MIMEType                        : video/quicktime\n" +
+		"[QuickTime]     CreateDate      : 2024:06:01 07:20:30\n" +
+		"[Composite]     Rotation        : 90\r\n" +
+		"[Bad\tTag      : value\n")
+
+	m := make(map[string][]byte)
+	if err := Unmarshal(data, m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"MIMEType":             "video/quicktime",
+		"QuickTime:CreateDate": "2024:06:01 07:20:30",
+		"Composite:Rotation":   "90",
+		"[Bad\tTag":            "value",
+	}
+	if len(m) != len(expected) {
+		t.Errorf("unexpected keys %v", m)
+	}
+	for k, v := range expected {
+		if string(m[k]) != v {
+			t.Errorf("%q: expected %q, got %q", k, v, m[k])
+		}
+	}
+
+	if err := Unmarshal([]byte("no separator\n"), m); err == nil {
+		t.Error("line without separator should fail")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	m := map[string][]byte{
+		"MIMEType":             nil,
+		"XMP:CreateDate":       nil,
+		"QuickTime:CreateDate": nil,
+		"EXIF:Make":            nil,
+		"PNG:ImageWidth":       nil,
+		"JFIF:ImageWidth":      nil,
+		"File:ImageWidth":      nil,
+	}
+
+	expected := map[string]string{
+		"CreateDate": "QuickTime:CreateDate",
+		"Make":       "EXIF:Make",
+		"ImageWidth": "File:ImageWidth",
+	}
+	// map order changes between runs, so the index is built several times
+	for range 10 {
+		index := Index(m)
+		if len(index) != len(expected) {
+			t.Fatalf("unexpected index %v", index)
+		}
+		for tag, key := range expected {
+			if index[tag] != key {
+				t.Errorf("%s: expected %q, got %q", tag, key, index[tag])
+			}
+		}
+	}
+
+	delete(m, "File:ImageWidth")
+	if key := Index(m)["ImageWidth"]; key != "JFIF:ImageWidth" {
+		t.Errorf("unlisted groups should be ordered by name, got %q", key)
+	}
+}
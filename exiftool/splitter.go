@@ -26,24 +26,21 @@ func splitReadyToken(data []byte, atEOF bool) (advance int, token []byte, err er
 }
 
 func DefaultSplitter(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if endPos := bytes.Index(data, endPattern); endPos >= 0 {
-		adv := endPos + len(endPattern)
-		tk := data[:endPos]
-		if strings.TrimSpace(string(tk)) == "" { // check case when there is only \n\n etc
-			tk = nil
-		}
-		return adv,
-			tk,
-			bufio.ErrFinalToken
+	// objects and reports before end of command are split first,
+	// whole output of command could be already in the buffer
+	body := data
+	endPos := bytes.Index(data, endPattern)
+	if endPos >= 0 {
+		body = data[:endPos]
 	}
 
 	startObject := []byte("======== ")
 	startReport := regexp.MustCompile(`\n+\s+`)
 
 	// Object start
-	if i := bytes.Index(data, startObject); i >= 0 {
+	if i := bytes.Index(body, startObject); i >= 0 {
 		// Find next object
-		if j := bytes.Index(data[i+len(startObject):], startObject); j > 0 {
+		if j := bytes.Index(body[i+len(startObject):], startObject); j > 0 {
 			// If so, then return token between them
 			return i + j + len(startObject),
 				data[:i+j+len(startObject)],
@@ -51,14 +48,25 @@ func DefaultSplitter(data []byte, atEOF bool) (advance int, token []byte, err er
 		}
 
 		// If there is not next object try to check exiftool report (usually started from empty spaces)
-		if reportIndices := startReport.FindIndex(data[i+len(startObject):]); reportIndices != nil {
+		if reportIndices := startReport.FindIndex(body[i+len(startObject):]); reportIndices != nil && (endPos < 0 || i+len(startObject)+reportIndices[1] < endPos) {
 			// Then return token between start pattern and report
-			return reportIndices[0] + len(startObject),
-				data[:reportIndices[0]+len(startObject)],
+			return i + reportIndices[0] + len(startObject),
+				data[:i+reportIndices[0]+len(startObject)],
 				nil
 		}
 	}
 
+	if endPos >= 0 {
+		adv := endPos + len(endPattern)
+		tk := data[:endPos]
+		if strings.TrimSpace(string(tk)) == "" { // check case when there is only \n\n etc
+			tk = nil
+		}
+		return adv,
+			tk,
+			bufio.ErrFinalToken
+	}
+
 	if atEOF {
 		return len(data), data, io.EOF
 	}
@@ -40,6 +40,15 @@ func TestDefaultSplitter(t *testing.T) {
 			},
 			expectedErr: bufio.ErrFinalToken,
 		},
+		{
+			name:  "Warning before object",
+			input: []byte("Warning: minor\n======== ./_MG_5112.JPG\nMIME Type : image/jpeg\n    1 image file read\n" + string(endPattern)),
+			expectedTokens: []string{
+				"Warning: minor\n======== ./_MG_5112.JPG\nMIME Type : image/jpeg",
+				"    1 image file read",
+			},
+			expectedErr: bufio.ErrFinalToken,
+		},
 		{
 			name:           "Only report",
 			input:          []byte("    1 image file read\n" + string(endPattern)),
@@ -58,6 +67,32 @@ func TestDefaultSplitter(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			runSplitter(t, DefaultSplitter, tt)
 		})
+		t.Run(tt.name+" at once", func(t *testing.T) {
+			runScanner(t, DefaultSplitter, tt)
+		})
+	}
+}
+
+// runScanner gives whole input to the splitter at once
+func runScanner(t *testing.T, splitter bufio.SplitFunc, tt testcase) {
+	scanner := bufio.NewScanner(strings.NewReader(string(tt.input)))
+	scanner.Split(splitter)
+
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != len(tt.expectedTokens) {
+		t.Fatalf("expected %d tokens, got %d: %q", len(tt.expectedTokens), len(tokens), tokens)
+	}
+	for i, expected := range tt.expectedTokens {
+		if strings.TrimSpace(tokens[i]) != strings.TrimSpace(expected) {
+			t.Errorf("token %d:\nexpected -> \n%v\ngot -> \n%v", i, expected, tokens[i])
+		}
 	}
 }
 
@@ -0,0 +1,357 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+const pluginEnv = "PERCEPLIB_TEST_PLUGIN"
+
+// TestMain lets test binary act as a plugin
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(pluginEnv); mode != "" {
+		runPlugin(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runPlugin answers with camera make as a tag, items with "crash", "hang" or "fail" in path misbehave.
+// Metadata plugin answers with item tags instead, group plugin groups items by camera make.
+// Deaf plugin doesn't read requests, orphan plugin leaves a child holding its stdout
+func runPlugin(mode string) {
+	out := json.NewEncoder(os.Stdout)
+
+	hs := Handshake{
+		Protocol:       ProtocolVersion,
+		Name:           "test_plugin",
+		Version:        "1.0",
+		DataProvider:   "exif",
+		ProcessingMode: "single",
+		Provides:       []api.Field{api.FieldDate, api.FieldMetadata},
+	}
+	switch mode {
+	case "group":
+		hs.ProcessingMode = "group"
+	case "raw", "metadata":
+		hs.DataProvider = mode
+	}
+	out.Encode(hs)
+
+	switch mode {
+	case "deaf":
+		time.Sleep(time.Hour)
+	case "orphan":
+		child := exec.Command("sleep", "5")
+		child.Stdout = os.Stdout
+		child.Start()
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+
+		if mode == "group" {
+			out.Encode(Response{ID: req.ID, Groups: groupByMake(req.Items)})
+			continue
+		}
+
+		switch {
+		case strings.Contains(req.Path, "crash"):
+			os.Exit(1)
+		case strings.Contains(req.Path, "hang"):
+			time.Sleep(time.Hour)
+		case strings.Contains(req.Path, "fail"):
+			out.Encode(Response{ID: req.ID, Error: "can't process"})
+			continue
+		}
+
+		tags := []string{strings.ToLower(req.Exif["Make"])}
+		if mode == "metadata" {
+			tags = req.Item.Tags
+		}
+
+		date := time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC)
+		confidence := 0.5
+		out.Encode(Response{
+			ID:         req.ID,
+			Confidence: &confidence,
+			Tags:       tags,
+			Values:     map[string]api.Value{"exif_count": api.IntValue(int64(len(req.Exif)))},
+			Set:        &Changes{Date: &date, Tags: []string{"external"}},
+		})
+	}
+}
+
+func groupByMake(items []*Request) []GroupData {
+	var groups []GroupData
+	index := make(map[string]int)
+	for _, item := range items {
+		camera := item.Exif["Make"]
+		i, ok := index[camera]
+		if !ok {
+			i = len(groups)
+			index[camera] = i
+			groups = append(groups, GroupData{ID: camera, Tags: []string{"camera"}})
+		}
+		groups[i].Items = append(groups[i].Items, item.ID)
+	}
+	return groups
+}
+
+func startPlugin(t *testing.T, mode string, opts Options) (*Perceptor, error) {
+	t.Helper()
+	t.Setenv(pluginEnv, mode)
+	p, err := New(opts, os.Args[0])
+	if err == nil {
+		t.Cleanup(func() { p.Close() })
+	}
+	return p, err
+}
+
+func TestPerceptor(t *testing.T) {
+	p, err := startPlugin(t, "single", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name() != "test_plugin" || p.Version() != "1.0" || p.DataProvider() != api.ExifDataProvider || p.ProcessingMode() != api.SingleItem {
+		t.Fatalf("unexpected handshake %+v", p.hs)
+	}
+
+	item := api.NewRawItem("a.jpg", api.RawExif{"Make": []byte("Canon")})
+	if err := p.Process(item); err != nil {
+		t.Fatal(err)
+	}
+
+	if !item.GetDate().Equal(time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", item.GetDate())
+	}
+	if item.SetBy(api.FieldDate) != "test_plugin" {
+		t.Errorf("date should be set by plugin, got %q", item.SetBy(api.FieldDate))
+	}
+	if tags := item.GetMetadata().Tags; len(tags) != 1 || tags[0] != "external" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	r, ok := item.GetResults().Get("test_plugin")
+	if !ok {
+		t.Fatal("result is not stored")
+	}
+	if r.Version != "1.0" || r.Confidence != 0.5 || len(r.Tags) != 1 || r.Tags[0] != "canon" {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if v, _ := r.Get("exif_count"); v.Kind() != api.KindInt {
+		t.Errorf("unexpected value %+v", v)
+	}
+
+	if err := p.Process(api.NewRawItem("fail.jpg", nil)); err == nil || err.Error() != "can't process" {
+		t.Errorf("expected plugin error, got %v", err)
+	}
+}
+
+func TestPerceptorRestart(t *testing.T) {
+	p, err := startPlugin(t, "single", Options{ItemTimeout: 200 * time.Millisecond, MaxRestarts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Process(api.NewRawItem("crash.jpg", nil)); !errors.Is(err, ErrCrashed) {
+		t.Errorf("expected ErrCrashed, got %v", err)
+	}
+	if err := p.Process(api.NewRawItem("a.jpg", nil)); err != nil {
+		t.Errorf("plugin should be restarted after crash, got %v", err)
+	}
+
+	if err := p.Process(api.NewRawItem("hang.jpg", nil)); !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	if err := p.Process(api.NewRawItem("a.jpg", nil)); err != nil {
+		t.Errorf("plugin should be restarted after timeout, got %v", err)
+	}
+
+	p.Process(api.NewRawItem("crash.jpg", nil))
+	p.Process(api.NewRawItem("crash.jpg", nil))
+	if err := p.Process(api.NewRawItem("a.jpg", nil)); !errors.Is(err, ErrClosed) {
+		t.Errorf("plugin should be closed after restarts in a row, got %v", err)
+	}
+}
+
+func TestPerceptorDeafPlugin(t *testing.T) {
+	p, err := startPlugin(t, "deaf", Options{ItemTimeout: 200 * time.Millisecond, MaxRestarts: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// request doesn't fit into pipe buffer
+	item := api.NewRawItem("a.jpg", api.RawExif{"Comment": make([]byte, 1<<20)})
+	done := make(chan error, 1)
+	go func() { done <- p.Process(item) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("expected ErrTimeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("write to plugin which doesn't read should time out")
+	}
+}
+
+func TestPerceptorOrphanOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin child is started with sleep")
+	}
+
+	for name, stop := range map[string]func(p *Perceptor) error{
+		"close":    (*Perceptor).Close,
+		"shutdown": (*Perceptor).Shutdown,
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := startPlugin(t, "orphan", Options{ShutdownTimeout: 200 * time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				stop(p)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(4 * time.Second):
+				t.Fatal("plugin child holding stdout should not block the host")
+			}
+		})
+	}
+}
+
+func TestPerceptorRestartHandshake(t *testing.T) {
+	p, err := startPlugin(t, "single", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// restarted plugin declares other data provider
+	t.Setenv(pluginEnv, "raw")
+	if err := p.Process(api.NewRawItem("crash.jpg", nil)); !errors.Is(err, ErrHandshake) {
+		t.Errorf("expected ErrHandshake, got %v", err)
+	}
+	if err := p.Process(api.NewRawItem("a.jpg", nil)); !errors.Is(err, ErrClosed) {
+		t.Errorf("plugin with changed handshake should be closed, got %v", err)
+	}
+}
+
+func TestPerceptorSidecar(t *testing.T) {
+	p, err := startPlugin(t, "single", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := api.NewRawItem("a.jpg", api.RawExif{"Make": []byte("Canon")})
+	item.SetSidecar(api.RawExif{"Make": []byte("Nikon")}, api.SidecarOverMain)
+	if err := p.Process(item); err != nil {
+		t.Fatal(err)
+	}
+
+	if r, _ := item.GetResults().Get("test_plugin"); len(r.Tags) != 1 || r.Tags[0] != "nikon" {
+		t.Errorf("plugin should get exif merged with sidecar, got %+v", r)
+	}
+}
+
+func TestPerceptorDataProviders(t *testing.T) {
+	p, err := startPlugin(t, "metadata", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DataProvider() != api.MetadataProvider {
+		t.Fatalf("unexpected data provider %v", p.DataProvider())
+	}
+
+	// items of pipeline are wrapped to give their contents
+	item := api.NewRawItem("a.jpg", nil)
+	item.AddTags("portrait")
+	if err := p.Process(api.NewFilePool(0).Wrap(item, "a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := item.GetResults().Get("test_plugin"); len(r.Tags) != 1 || r.Tags[0] != "portrait" {
+		t.Errorf("plugin should get item metadata, got %+v", r)
+	}
+
+	p, err = startPlugin(t, "raw", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Process(api.NewRawItem("", nil)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("raw plugin needs path of the item, got %v", err)
+	}
+	if err := p.Process(api.NewRawItem("a.jpg", nil)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPerceptorGroup(t *testing.T) {
+	p, err := startPlugin(t, "group", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ProcessingMode() != api.ItemGroup {
+		t.Fatalf("unexpected processing mode %v", p.ProcessingMode())
+	}
+
+	items := []api.RawItemR{
+		api.NewRawItem("a.jpg", api.RawExif{"Make": []byte("Canon")}),
+		api.NewRawItem("b.jpg", api.RawExif{"Make": []byte("Nikon")}),
+		api.NewRawItem("c.jpg", api.RawExif{"Make": []byte("Canon")}),
+	}
+	groups, err := p.ProcessGroup(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(groups) != 2 || groups[0].GetGroupID() != "Canon" || len(groups[0].GetItems()) != 2 || groups[0].GetItems()[1] != items[2] {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	r, ok := items[2].(*api.RawItem).GetResults().Get("test_plugin")
+	if !ok {
+		t.Fatal("result is not stored")
+	}
+	pos, _ := r.Get("position")
+	if n, _ := pos.AsInt(); n != 2 {
+		t.Errorf("unexpected position %+v", pos)
+	}
+	if len(r.Tags) != 1 || r.Tags[0] != "camera" {
+		t.Errorf("unexpected tags %v", r.Tags)
+	}
+
+	if err := p.Process(items[0]); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("group plugin can't process single items, got %v", err)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	tests := []Handshake{
+		{Protocol: 2, Name: "a", DataProvider: "exif", ProcessingMode: "single"},
+		{Protocol: 1, DataProvider: "exif", ProcessingMode: "single"},
+		{Protocol: 1, Name: "a", DataProvider: "video", ProcessingMode: "single"},
+	}
+	for _, hs := range tests {
+		if err := hs.validate(); !errors.Is(err, ErrHandshake) {
+			t.Errorf("%+v: expected ErrHandshake, got %v", hs, err)
+		}
+	}
+}
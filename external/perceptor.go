@@ -0,0 +1,262 @@
+/*
+Package external runs perceptors implemented by external executables.
+
+Plugin reads requests from stdin and writes responses to stdout, one json per line.
+The first line written by plugin is Handshake, then every Request is answered by
+Response with the same id. Plugin stderr is not a part of the protocol.
+
+Data provider of the handshake defines request contents: "exif" plugins get exif and item data,
+"raw" plugins read the file by request path themselves, "metadata" plugins also get metadata
+and results of earlier perceptors. Plugins in "single" mode get request for every item,
+plugins in "group" mode get all items in one request and answer with Response.Groups.
+*/
+package external
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+// Perceptor is an api.ExifPerceptor backed by plugin process
+type Perceptor struct {
+	process *Process
+	hs      Handshake
+	nextID  atomic.Uint64
+}
+
+var (
+	_ api.ExifPerceptor  = (*Perceptor)(nil)
+	_ api.GroupPerceptor = (*Perceptor)(nil)
+	_ api.Versioned      = (*Perceptor)(nil)
+	_ api.Dependent      = (*Perceptor)(nil)
+)
+
+// New starts plugin executable, name and abilities of perceptor are taken from its handshake
+func New(opts Options, name string, arg ...string) (*Perceptor, error) {
+	process, err := StartProcess(opts, name, arg...)
+	if err != nil {
+		return nil, err
+	}
+	return &Perceptor{process: process, hs: process.Handshake()}, nil
+}
+
+func (p *Perceptor) Name() string          { return p.hs.Name }
+func (p *Perceptor) Version() string       { return p.hs.Version }
+func (p *Perceptor) Requires() []api.Field { return p.hs.Requires }
+func (p *Perceptor) Provides() []api.Field { return p.hs.Provides }
+
+func (p *Perceptor) DataProvider() api.DataProviderType {
+	return dataProviders[p.hs.DataProvider]
+}
+
+func (p *Perceptor) ProcessingMode() api.ProcessingMode {
+	return processingModes[p.hs.ProcessingMode]
+}
+
+// NewProcessor runs plugins in single mode, see NewGroupProcessor
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+// NewGroupProcessor runs plugins in group mode, see NewProcessor
+func (p *Perceptor) NewGroupProcessor(chin <-chan api.RawItemR, chout chan<- api.ItemGroupR, logger *l.Logger) chain.Processor {
+	return chain.NewGroupCollector(chin, chout, &groupProcessor{perceptor: p})
+}
+
+// Process sends item to the plugin in single mode and applies its response
+func (p *Perceptor) Process(item api.RawItemR) error {
+	if p.ProcessingMode() != api.SingleItem {
+		return fmt.Errorf("%w: single item in %s mode", ErrUnsupported, p.hs.ProcessingMode)
+	}
+
+	req, err := newRequest(p.nextID.Add(1), item, p.DataProvider())
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.process.Call(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return p.apply(item, resp)
+}
+
+// ProcessGroup sends all items to the plugin in group mode and returns groups from its response.
+// Every item gets result with group id, its position and size of the group, and tags of the group
+func (p *Perceptor) ProcessGroup(items []api.RawItemR) ([]api.ItemGroupR, error) {
+	if p.ProcessingMode() != api.ItemGroup {
+		return nil, fmt.Errorf("%w: groups in %s mode", ErrUnsupported, p.hs.ProcessingMode)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	req := &Request{ID: p.nextID.Add(1), Items: make([]*Request, len(items))}
+	for i, item := range items {
+		r, err := newRequest(uint64(i), item, p.DataProvider())
+		if err != nil {
+			return nil, err
+		}
+		req.Items[i] = r
+	}
+
+	resp, err := p.process.Call(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return p.applyGroups(items, resp.Groups)
+}
+
+func (p *Perceptor) applyGroups(items []api.RawItemR, groups []GroupData) ([]api.ItemGroupR, error) {
+	grouped := make([]bool, len(items))
+	res := make([]api.ItemGroupR, 0, len(groups))
+	for _, g := range groups {
+		group := &api.Group{ID: g.ID, Items: make([]api.RawItemR, 0, len(g.Items))}
+		for _, id := range g.Items {
+			if id >= uint64(len(items)) || grouped[id] {
+				return nil, fmt.Errorf("external: malformed response: item %d in group %q", id, g.ID)
+			}
+			grouped[id] = true
+			group.Items = append(group.Items, items[id])
+		}
+		res = append(res, group)
+	}
+	if i := slices.Index(grouped, false); i >= 0 {
+		return nil, fmt.Errorf("external: malformed response: item %d is not grouped", i)
+	}
+	if err := api.CheckResults(items...); err != nil {
+		return nil, err
+	}
+
+	for i, g := range groups {
+		for j, item := range res[i].GetItems() {
+			r := api.NewResult(p).
+				Set("group_id", api.StringValue(g.ID)).
+				Set("position", api.IntValue(int64(j+1))).
+				Set("size", api.IntValue(int64(len(g.Items)))).
+				AddTags(g.Tags...)
+			if err := api.SetResult(item, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+func (p *Perceptor) apply(item api.RawItemR, resp *Response) error {
+	hasResult := resp.Confidence != nil || len(resp.Tags) > 0 || len(resp.Values) > 0
+	if hasResult {
+		if err := api.CheckResults(item); err != nil {
+			return err
+		}
+	}
+
+	if resp.Set != nil {
+		if err := p.applyChanges(item, resp.Set); err != nil {
+			return err
+		}
+	}
+	if !hasResult {
+		return nil
+	}
+
+	r := api.NewResult(p).AddTags(resp.Tags...)
+	if resp.Confidence != nil {
+		r.Confidence = *resp.Confidence
+	}
+	for k, v := range resp.Values {
+		r.Set(k, v)
+	}
+	return api.SetResult(item, r)
+}
+
+func (p *Perceptor) applyChanges(item api.RawItemR, c *Changes) error {
+	editor, ok := api.EditorOf(item, p.Name())
+	if !ok {
+		return errors.New("item is read only")
+	}
+
+	if c.Guid != "" {
+		ge, ok := editor.(api.GuidEditor)
+		if !ok {
+			return errors.New("item doesn't support guid changes")
+		}
+		ge.SetGuid(c.Guid)
+	}
+	if c.Date != nil {
+		editor.SetDate(*c.Date)
+	}
+	if c.Size != nil {
+		editor.SetSize(*c.Size)
+	}
+	if c.Ratio != nil {
+		editor.SetRatio(*c.Ratio)
+	}
+
+	if c.Location == nil && len(c.Tags) == 0 && len(c.Categories) == 0 && c.Event == "" {
+		return nil
+	}
+	me, ok := editor.(api.MetadataDataEditor)
+	if !ok {
+		return errors.New("item doesn't support metadata changes")
+	}
+	if c.Location != nil {
+		me.SetLocation(*c.Location)
+	}
+	me.AddTags(c.Tags...)
+	me.AddCategories(c.Categories...)
+	if c.Event != "" {
+		me.SetEvent(c.Event)
+	}
+	return nil
+}
+
+// Close kills plugin process
+func (p *Perceptor) Close() error {
+	return p.process.Close()
+}
+
+// Shutdown lets plugin finish and waits until it exits
+func (p *Perceptor) Shutdown() error {
+	return p.process.Shutdown()
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	if err := pr.perceptor.Process(item); err != nil {
+		return nil, fmt.Errorf("%s: %w", pr.perceptor.Name(), err)
+	}
+	return item, nil
+}
+
+// Stop keeps plugin running, it could be used by other processors, see Perceptor.Close
+func (pr *processor) Stop() {}
+
+type groupProcessor struct {
+	perceptor *Perceptor
+}
+
+func (pr *groupProcessor) Group(items []api.RawItemR) ([]api.ItemGroupR, error) {
+	groups, err := pr.perceptor.ProcessGroup(items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pr.perceptor.Name(), err)
+	}
+	return groups, nil
+}
+
+func (pr *groupProcessor) Stop() {}
@@ -0,0 +1,302 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+var (
+	ErrHandshake   = errors.New("external: bad handshake")
+	ErrUnsupported = errors.New("external: unsupported")
+	ErrTimeout     = errors.New("external: timeout")
+	ErrCrashed     = errors.New("external: plugin exited")
+	ErrClosed      = errors.New("external: plugin is closed")
+)
+
+const (
+	DefaultStartTimeout    = 10 * time.Second
+	DefaultItemTimeout     = 30 * time.Second
+	DefaultShutdownTimeout = 10 * time.Second
+	DefaultMaxRestarts     = 3
+)
+
+// waitDelay bounds waiting for output of killed plugin, its children could keep pipes open
+const waitDelay = time.Second
+
+// Options configure plugin process
+type Options struct {
+	StartTimeout    time.Duration // time for handshake, DefaultStartTimeout if zero
+	ItemTimeout     time.Duration // time for single item, group requests get it for every item, DefaultItemTimeout if zero
+	ShutdownTimeout time.Duration // time to exit after stdin is closed, DefaultShutdownTimeout if zero
+	MaxRestarts     int           // restarts in a row before plugin is closed, DefaultMaxRestarts if zero, negative means none
+	Stderr          io.Writer     // plugin stderr, discarded if nil
+}
+
+func (o Options) startTimeout() time.Duration {
+	if o.StartTimeout <= 0 {
+		return DefaultStartTimeout
+	}
+	return o.StartTimeout
+}
+
+func (o Options) itemTimeout() time.Duration {
+	if o.ItemTimeout <= 0 {
+		return DefaultItemTimeout
+	}
+	return o.ItemTimeout
+}
+
+func (o Options) shutdownTimeout() time.Duration {
+	if o.ShutdownTimeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return o.ShutdownTimeout
+}
+
+func (o Options) maxRestarts() int {
+	if o.MaxRestarts == 0 {
+		return DefaultMaxRestarts
+	}
+	return max(o.MaxRestarts, 0)
+}
+
+// Process wraps plugin executable which handles requests one by one.
+// Process is restarted if plugin crashes or doesn't answer in time.
+// Process is safe for concurrent use by multiple goroutines.
+type Process struct {
+	exec     string
+	args     []string
+	opts     Options
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    <-chan []byte
+	hs       Handshake
+	restarts int
+	done     bool
+}
+
+// StartProcess runs plugin and reads its handshake
+func StartProcess(opts Options, name string, arg ...string) (*Process, error) {
+	p := &Process{exec: name, args: arg, opts: opts}
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Handshake returns handshake of running plugin
+func (p *Process) Handshake() Handshake {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hs
+}
+
+func (p *Process) start() error {
+	cmd := exec.Command(p.exec, p.args...)
+	cmd.Stderr = p.opts.Stderr
+	cmd.WaitDelay = waitDelay
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// lines are read in background to be able to stop waiting on timeout
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(nil, 64<<20)
+		for scanner.Scan() {
+			lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+
+	p.cmd, p.stdin, p.lines = cmd, stdin, lines
+
+	var hs Handshake
+	if err := p.read(&hs, p.opts.startTimeout()); err != nil {
+		p.kill()
+		return fmt.Errorf("%w: %w", ErrHandshake, err)
+	}
+	if err := hs.validate(); err != nil {
+		p.kill()
+		return err
+	}
+	// perceptor is scheduled and cached by the first handshake
+	if p.hs.Name != "" && !p.hs.equal(hs) {
+		p.kill()
+		return fmt.Errorf("%w: plugin %q restarted with other handshake %+v", ErrHandshake, p.hs.Name, hs)
+	}
+	p.hs = hs
+	return nil
+}
+
+// write sends line to the plugin, plugin which doesn't read stdin fails with ErrTimeout
+func (p *Process) write(data []byte, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// pending write is interrupted by kill closing stdin
+	written := make(chan error, 1)
+	go func() {
+		_, err := p.stdin.Write(data)
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			return ErrCrashed
+		}
+		return nil
+	case <-timer.C:
+		return ErrTimeout
+	}
+}
+
+func (p *Process) read(v any, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return ErrCrashed
+		}
+		return json.Unmarshal(line, v)
+	case <-timer.C:
+		return ErrTimeout
+	}
+}
+
+func (p *Process) kill() {
+	p.cmd.Process.Kill()
+	p.stdin.Close()
+	p.drain(waitDelay)
+	p.cmd.Wait()
+}
+
+// drain skips output until plugin closes stdout or timeout expires,
+// the rest is skipped in background until Wait closes the pipe, so the reader doesn't block
+func (p *Process) drain(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case _, ok := <-p.lines:
+			if !ok {
+				return true
+			}
+		case <-timer.C:
+			go func(lines <-chan []byte) {
+				for range lines {
+				}
+			}(p.lines)
+			return false
+		}
+	}
+}
+
+// restart replaces broken process, plugin is closed when restarts are exhausted
+func (p *Process) restart() error {
+	p.kill()
+	if p.restarts >= p.opts.maxRestarts() {
+		p.done = true
+		return ErrClosed
+	}
+	p.restarts++
+	if err := p.start(); err != nil {
+		p.done = true
+		return err
+	}
+	return nil
+}
+
+// Call sends request and waits for the response with the same id.
+// Process is restarted on timeout or crash and call fails
+func (p *Process) Call(req *Request) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return nil, ErrClosed
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// request is written and answered within the same deadline
+	timeout := p.opts.itemTimeout() * time.Duration(max(len(req.Items), 1))
+	deadline := time.Now().Add(timeout)
+	callErr := p.write(append(data, '\n'), timeout)
+	for callErr == nil {
+		var resp Response
+		err := p.read(&resp, time.Until(deadline))
+		if errors.Is(err, ErrTimeout) || errors.Is(err, ErrCrashed) {
+			callErr = err
+			break
+		}
+		if err != nil {
+			// plugin is out of sync with the protocol
+			callErr = fmt.Errorf("external: malformed response: %w", err)
+			break
+		}
+		if resp.ID == req.ID {
+			p.restarts = 0
+			return &resp, nil
+		}
+	}
+
+	if err := p.restart(); err != nil {
+		return nil, errors.Join(callErr, err)
+	}
+	return nil, callErr
+}
+
+// Close kills plugin immediately
+func (p *Process) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return nil
+	}
+	p.done = true
+	p.kill()
+	return nil
+}
+
+// Shutdown closes stdin of the plugin and waits until it exits,
+// plugin which doesn't close its output in Options.ShutdownTimeout is killed
+func (p *Process) Shutdown() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return nil
+	}
+	p.done = true
+	p.stdin.Close()
+	if !p.drain(p.opts.shutdownTimeout()) {
+		p.cmd.Process.Kill()
+	}
+	return p.cmd.Wait()
+}
@@ -0,0 +1,156 @@
+package external
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+// ProtocolVersion is a version of the protocol spoken by the host
+const ProtocolVersion = 1
+
+// Handshake is the first line written by the plugin after start, e.g.
+//
+//	{"protocol":1,"name":"faces","version":"0.3","data_provider":"exif","processing_mode":"single","provides":["metadata"]}
+type Handshake struct {
+	Protocol       int         `json:"protocol"`
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	DataProvider   string      `json:"data_provider"`
+	ProcessingMode string      `json:"processing_mode"`
+	Requires       []api.Field `json:"requires,omitempty"`
+	Provides       []api.Field `json:"provides,omitempty"`
+}
+
+var dataProviders = map[string]api.DataProviderType{
+	"exif":     api.ExifDataProvider,
+	"raw":      api.RawDataProvider,
+	"metadata": api.MetadataProvider,
+}
+
+var processingModes = map[string]api.ProcessingMode{
+	"single": api.SingleItem,
+	"group":  api.ItemGroup,
+}
+
+func (h Handshake) validate() error {
+	if h.Protocol != ProtocolVersion {
+		return fmt.Errorf("%w: protocol %d, expected %d", ErrHandshake, h.Protocol, ProtocolVersion)
+	}
+	if h.Name == "" {
+		return fmt.Errorf("%w: empty name", ErrHandshake)
+	}
+	if _, ok := dataProviders[h.DataProvider]; !ok {
+		return fmt.Errorf("%w: unknown data provider %q", ErrHandshake, h.DataProvider)
+	}
+	if _, ok := processingModes[h.ProcessingMode]; !ok {
+		return fmt.Errorf("%w: unknown processing mode %q", ErrHandshake, h.ProcessingMode)
+	}
+	return nil
+}
+
+// equal reports whether handshakes declare the same plugin
+func (h Handshake) equal(o Handshake) bool {
+	return h.Protocol == o.Protocol && h.Name == o.Name && h.Version == o.Version &&
+		h.DataProvider == o.DataProvider && h.ProcessingMode == o.ProcessingMode &&
+		slices.Equal(h.Requires, o.Requires) && slices.Equal(h.Provides, o.Provides)
+}
+
+// Request is written by the host for every item, one json per line.
+// In group mode all collected items are sent at once in Items, ids of items are their indexes
+type Request struct {
+	ID    uint64            `json:"id"`
+	Path  string            `json:"path,omitempty"`
+	Exif  map[string]string `json:"exif,omitempty"` // exif of main file merged with sidecars, keys as printed by exiftool, e.g. "QuickTime:CreateDate"
+	Item  *ItemData         `json:"item,omitempty"`
+	Items []*Request        `json:"items,omitempty"`
+}
+
+// ItemData is item data set by earlier perceptors,
+// metadata and results are sent only to plugins with "metadata" data provider
+type ItemData struct {
+	Guid       string        `json:"guid,omitempty"`
+	Date       *time.Time    `json:"date,omitempty"`
+	Size       *api.Size     `json:"size,omitempty"`
+	Location   *api.Location `json:"location,omitempty"`
+	Tags       []string      `json:"tags,omitempty"`
+	Categories []string      `json:"categories,omitempty"`
+	Event      string        `json:"event,omitempty"`
+	Results    *api.Results  `json:"results,omitempty"`
+}
+
+// Response is written by the plugin for every request, one json per line.
+// Values use typed form of api.Value, e.g. {"kind":"float","value":0.5}
+type Response struct {
+	ID         uint64               `json:"id"`
+	Error      string               `json:"error,omitempty"`
+	Confidence *float64             `json:"confidence,omitempty"`
+	Tags       []string             `json:"tags,omitempty"`
+	Values     map[string]api.Value `json:"values,omitempty"`
+	Set        *Changes             `json:"set,omitempty"`
+	Groups     []GroupData          `json:"groups,omitempty"` // group mode only
+}
+
+// GroupData is a group of items found by the plugin in group mode, every item should belong to exactly one group
+type GroupData struct {
+	ID    string   `json:"id"`
+	Items []uint64 `json:"items"`          // ids of items in the request
+	Tags  []string `json:"tags,omitempty"` // result tags of every item of the group
+}
+
+// Changes are item fields changed by the plugin, plugin should declare them in Handshake.Provides
+type Changes struct {
+	Guid       string        `json:"guid,omitempty"`
+	Date       *time.Time    `json:"date,omitempty"`
+	Size       *api.Size     `json:"size,omitempty"`
+	Ratio      *api.Size     `json:"ratio,omitempty"`
+	Location   *api.Location `json:"location,omitempty"`
+	Tags       []string      `json:"tags,omitempty"`
+	Categories []string      `json:"categories,omitempty"`
+	Event      string        `json:"event,omitempty"`
+}
+
+func newRequest(id uint64, item api.RawItemR, provider api.DataProviderType) (*Request, error) {
+	exif, ok := api.RawExifOf(item)
+	if !ok {
+		return nil, fmt.Errorf("%w: item doesn't provide raw exif", ErrUnsupported)
+	}
+
+	req := &Request{
+		ID:   id,
+		Exif: make(map[string]string, len(exif)),
+		Item: &ItemData{Guid: item.GetGuid()},
+	}
+	for k, v := range exif {
+		req.Exif[k] = string(v)
+	}
+	req.Path, _ = api.PathOf(item)
+	if date := item.GetDate(); !date.IsZero() {
+		req.Item.Date = &date
+	}
+	if size := item.GetSize(); size != (api.Size{}) {
+		req.Item.Size = &size
+	}
+
+	switch provider {
+	case api.RawDataProvider:
+		// plugin reads the file itself
+		if req.Path == "" {
+			return nil, fmt.Errorf("%w: item doesn't provide path", ErrUnsupported)
+		}
+	case api.MetadataProvider:
+		if mp, ok := api.MetadataOf(item); ok {
+			if location, ok := mp.GetLocation(); ok {
+				req.Item.Location = &location
+			}
+			metadata := mp.GetMetadata()
+			req.Item.Tags, req.Item.Categories, req.Item.Event = metadata.Tags, metadata.Categories, metadata.Event
+		}
+		if rs, ok := api.ResultsOf(item); ok {
+			req.Item.Results = rs
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,136 @@
+/*
+Package imaging decodes images of items and prepares them for analysis by raw perceptors.
+*/
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+var ErrUnsupported = errors.New("imaging: unsupported image format")
+
+// Source tells what was decoded
+type Source string
+
+const (
+	SourceImage   Source = "image"   // file itself
+	SourcePreview Source = "preview" // jpeg embedded into the file, e.g. preview of raw
+)
+
+// previewTags are exiftool offset and length tags of embedded jpegs, larger ones first
+var previewTags = [][2]string{
+	{"JpgFromRawStart", "JpgFromRawLength"},
+	{"PreviewImageStart", "PreviewImageLength"},
+	{"OtherImageStart", "OtherImageLength"},
+	{"ThumbnailOffset", "ThumbnailLength"},
+}
+
+// maxCandidates limits amount of embedded jpegs checked while scanning the file
+const maxCandidates = 32
+
+// Decode decodes file with standard library decoders (jpeg, png, gif).
+// Files of other formats are decoded from the largest embedded jpeg if preview is allowed,
+// it is located by exif tags and by scanning the file if tags are missing
+func Decode(content api.RawContent, exif api.ExifProvider, preview bool) (image.Image, Source, error) {
+	r := io.NewSectionReader(content, 0, content.Size())
+	img, _, err := image.Decode(r)
+	if err == nil {
+		return img, SourceImage, nil
+	}
+	if !errors.Is(err, image.ErrFormat) || !preview {
+		return nil, "", err
+	}
+
+	img, err = DecodePreview(content, exif)
+	if err != nil {
+		return nil, "", err
+	}
+	return img, SourcePreview, nil
+}
+
+// DecodePreview decodes the largest jpeg embedded into the file
+func DecodePreview(content api.RawContent, exif api.ExifProvider) (image.Image, error) {
+	size := content.Size()
+
+	if exif != nil {
+		for _, tags := range previewTags {
+			start, errStart := api.ExifInt(exif, tags[0])
+			length, errLength := api.ExifInt(exif, tags[1])
+			if errStart != nil || errLength != nil || start <= 0 || length <= 0 || start+length > size {
+				continue
+			}
+			if img, err := jpeg.Decode(io.NewSectionReader(content, start, length)); err == nil {
+				return img, nil
+			}
+		}
+	}
+
+	offsets, err := scanJPEG(io.NewSectionReader(content, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	best, bestArea := int64(-1), 0
+	for _, off := range offsets {
+		cfg, err := jpeg.DecodeConfig(io.NewSectionReader(content, off, size-off))
+		if err != nil {
+			continue
+		}
+		if area := cfg.Width * cfg.Height; area > bestArea {
+			best, bestArea = off, area
+		}
+	}
+	if best < 0 {
+		return nil, ErrUnsupported
+	}
+	return jpeg.Decode(io.NewSectionReader(content, best, size-best))
+}
+
+// scanJPEG returns offsets of jpeg start markers followed by another marker, file start is skipped
+func scanJPEG(r io.Reader) ([]int64, error) {
+	marker := []byte{0xFF, 0xD8, 0xFF}
+
+	br := bufio.NewReaderSize(r, 1<<16)
+	buf := make([]byte, 1<<16)
+	var (
+		offsets []int64
+		carry   []byte
+		pos     int64 // file offset of carry start
+	)
+	for len(offsets) < maxCandidates {
+		n, err := br.Read(buf)
+		data := append(carry, buf[:n]...)
+		for i := 0; ; {
+			j := bytes.Index(data[i:], marker)
+			if j < 0 {
+				break
+			}
+			if off := pos + int64(i+j); off > 0 {
+				offsets = append(offsets, off)
+			}
+			i += j + 1
+		}
+
+		// marker could be split between reads
+		keep := min(len(data), len(marker)-1)
+		pos += int64(len(data) - keep)
+		carry = append([]byte(nil), data[len(data)-keep:]...)
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return offsets, nil
+}
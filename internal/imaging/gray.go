@@ -0,0 +1,122 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+// Gray is a small grayscale image with luminance values from 0 to 255
+type Gray struct {
+	W, H int
+	Pix  []float64 // row by row
+}
+
+func NewGray(w, h int) *Gray {
+	return &Gray{W: w, H: h, Pix: make([]float64, w*h)}
+}
+
+func (g *Gray) At(x, y int) float64 {
+	return g.Pix[y*g.W+x]
+}
+
+func (g *Gray) Set(x, y int, v float64) {
+	g.Pix[y*g.W+x] = v
+}
+
+// luminance returns accessor of pixel luminance, fast for types produced by standard decoders
+func luminance(img image.Image) func(x, y int) float64 {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) float64 { return float64(img.Y[img.YOffset(x, y)]) }
+	case *image.Gray:
+		return func(x, y int) float64 { return float64(img.GrayAt(x, y).Y) }
+	case *image.RGBA:
+		return func(x, y int) float64 {
+			c := img.RGBAAt(x, y)
+			return luma(float64(c.R), float64(c.G), float64(c.B))
+		}
+	case *image.NRGBA:
+		return func(x, y int) float64 {
+			c := img.NRGBAAt(x, y)
+			a := float64(c.A) / 255
+			return luma(float64(c.R)*a, float64(c.G)*a, float64(c.B)*a)
+		}
+	}
+	return func(x, y int) float64 {
+		c := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		return float64(c.Y)
+	}
+}
+
+// luma is ITU-R BT.601 luminance, as used by jpeg
+func luma(r, g, b float64) float64 {
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+// Grayscale downscales image to w x h by averaging source pixels covered by each target pixel.
+// Smaller images are upscaled by repeating pixels
+func Grayscale(img image.Image, w, h int) *Gray {
+	lum := luminance(img)
+	b := img.Bounds()
+	g := NewGray(w, h)
+
+	for ty := 0; ty < h; ty++ {
+		y0, y1 := span(ty, h, b.Min.Y, b.Dy())
+		for tx := 0; tx < w; tx++ {
+			x0, x1 := span(tx, w, b.Min.X, b.Dx())
+			sum := 0.0
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					sum += lum(x, y)
+				}
+			}
+			g.Set(tx, ty, sum/float64((x1-x0)*(y1-y0)))
+		}
+	}
+	return g
+}
+
+// span returns source range of target pixel i of n, it covers at least one source pixel
+func span(i, n, origin, size int) (int, int) {
+	from := origin + i*size/n
+	to := origin + (i+1)*size/n
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// Orient transforms image stored with given orientation to the way it is displayed
+func (g *Gray) Orient(o api.Orientation) *Gray {
+	w, h := g.W, g.H
+	if o.SwapsDimensions() {
+		w, h = h, w
+	}
+
+	res := NewGray(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := x, y
+			switch o {
+			case api.OrientationMirrorHorizontal:
+				sx = g.W - 1 - x
+			case api.OrientationRotate180:
+				sx, sy = g.W-1-x, g.H-1-y
+			case api.OrientationMirrorVertical:
+				sy = g.H - 1 - y
+			case api.OrientationMirrorHorizontalRotate270:
+				sx, sy = y, x
+			case api.OrientationRotate90:
+				sx, sy = y, g.H-1-x
+			case api.OrientationMirrorHorizontalRotate90:
+				sx, sy = g.W-1-y, g.H-1-x
+			case api.OrientationRotate270:
+				sx, sy = g.W-1-y, x
+			}
+			res.Set(x, y, g.At(sx, sy))
+		}
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func content(t *testing.T, name string, data []byte) api.RawContent {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := api.NewFilePool(0).Open(path)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("png", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, solid(3, 2, color.White)); err != nil {
+			t.Fatal(err)
+		}
+		img, src, err := Decode(content(t, "a.png", buf.Bytes()), nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src != SourceImage || img.Bounds().Dx() != 3 {
+			t.Errorf("unexpected %s %v", src, img.Bounds())
+		}
+	})
+
+	// raw file is imitated by garbage with thumbnail and preview inside
+	small, large := encodeJPEG(t, solid(16, 8, color.Black)), encodeJPEG(t, solid(64, 32, color.White))
+	var raw []byte
+	raw = append(raw, "II*\x00garbage"...)
+	raw = append(raw, small...)
+	raw = append(raw, 0xFF, 0xD8, 0xFF, 0x00, 0x01)
+	previewStart := len(raw)
+	raw = append(raw, large...)
+	raw = append(raw, "tail"...)
+
+	t.Run("scanned preview", func(t *testing.T) {
+		img, src, err := Decode(content(t, "a.cr2", raw), nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src != SourcePreview || img.Bounds().Dx() != 64 {
+			t.Errorf("expected the largest preview, got %s %v", src, img.Bounds())
+		}
+	})
+
+	t.Run("preview by tags", func(t *testing.T) {
+		exif := api.RawExif{
+			"PreviewImageStart":  []byte("11"),
+			"PreviewImageLength": []byte(fmt.Sprint(len(small))),
+			"ThumbnailOffset":    []byte(fmt.Sprint(previewStart)),
+			"ThumbnailLength":    []byte(fmt.Sprint(len(large))),
+		}
+		img, err := DecodePreview(content(t, "a.nef", raw), exif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != 16 {
+			t.Errorf("expected preview pointed by the first tags, got %v", img.Bounds())
+		}
+	})
+
+	t.Run("preview disabled", func(t *testing.T) {
+		if _, _, err := Decode(content(t, "a.cr2", raw), nil, false); !errors.Is(err, image.ErrFormat) {
+			t.Errorf("expected image.ErrFormat, got %v", err)
+		}
+	})
+
+	t.Run("no preview", func(t *testing.T) {
+		if _, _, err := Decode(content(t, "a.heic", []byte("ftypheic....")), nil, true); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("expected ErrUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestGrayscale(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	copy(img.Pix, []byte{0, 10, 100, 200, 20, 30, 100, 0})
+
+	g := Grayscale(img, 2, 1)
+	if g.At(0, 0) != 15 || g.At(1, 0) != 100 {
+		t.Errorf("unexpected averages %v", g.Pix)
+	}
+
+	g = Grayscale(img, 8, 4)
+	if g.At(7, 3) != 0 || g.At(0, 0) != 0 || g.At(2, 0) != 10 {
+		t.Errorf("unexpected upscale %v", g.Pix)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 1 2 3
+	// 4 5 6
+	g := &Gray{W: 3, H: 2, Pix: []float64{1, 2, 3, 4, 5, 6}}
+
+	tests := []struct {
+		o        api.Orientation
+		expected []float64
+	}{
+		{api.OrientationNormal, []float64{1, 2, 3, 4, 5, 6}},
+		{api.OrientationMirrorHorizontal, []float64{3, 2, 1, 6, 5, 4}},
+		{api.OrientationRotate180, []float64{6, 5, 4, 3, 2, 1}},
+		{api.OrientationMirrorVertical, []float64{4, 5, 6, 1, 2, 3}},
+		{api.OrientationMirrorHorizontalRotate270, []float64{1, 4, 2, 5, 3, 6}},
+		{api.OrientationRotate90, []float64{4, 1, 5, 2, 6, 3}},
+		{api.OrientationMirrorHorizontalRotate90, []float64{6, 3, 5, 2, 4, 1}},
+		{api.OrientationRotate270, []float64{3, 6, 2, 5, 1, 4}},
+	}
+	for _, tt := range tests {
+		res := g.Orient(tt.o)
+		if fmt.Sprint(res.Pix) != fmt.Sprint(tt.expected) {
+			t.Errorf("%d: expected %v, got %v", tt.o, tt.expected, res.Pix)
+		}
+		if tt.o.SwapsDimensions() && (res.W != 2 || res.H != 3) {
+			t.Errorf("%d: dimensions should be swapped", tt.o)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.SetNRGBA(x, 0, color.NRGBA{255, 0, 0, 255})
+		img.SetNRGBA(x, 1, color.NRGBA{0, 0, 255, 255})
+	}
+	img.SetNRGBA(3, 1, color.NRGBA{0, 255, 0, 0})
+
+	res := Resize(img, 2, 1)
+	if c := res.NRGBAAt(0, 0); c != (color.NRGBA{128, 0, 128, 255}) {
+		t.Errorf("unexpected average %v", c)
+	}
+	if c := res.NRGBAAt(1, 0); c != (color.NRGBA{170, 0, 85, 191}) {
+		t.Errorf("transparent pixel should not change color, got %v", c)
+	}
+
+	for _, tt := range [][4]int{{4000, 3000, 64, 48}, {30, 60, 30, 60}, {10, 1000, 1, 64}} {
+		if w, h := Fit(tt[0], tt[1], 64); w != tt[2] || h != tt[3] {
+			t.Errorf("%v: unexpected %dx%d", tt, w, h)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// rgba returns accessor of non-premultiplied 8 bit pixel color, fast for types produced by standard decoders
+func rgba(img image.Image) func(x, y int) color.NRGBA {
+	switch img := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) color.NRGBA {
+			yy := img.Y[img.YOffset(x, y)]
+			ci := img.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(yy, img.Cb[ci], img.Cr[ci])
+			return color.NRGBA{r, g, b, 255}
+		}
+	case *image.NRGBA:
+		return img.NRGBAAt
+	case *image.Gray:
+		return func(x, y int) color.NRGBA {
+			v := img.GrayAt(x, y).Y
+			return color.NRGBA{v, v, v, 255}
+		}
+	}
+	return func(x, y int) color.NRGBA {
+		return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	}
+}
+
+// Fit returns size with the longest side not larger than limit keeping aspect ratio,
+// smaller sizes are not changed
+func Fit(w, h, limit int) (int, int) {
+	if w <= limit && h <= limit {
+		return w, h
+	}
+	if w >= h {
+		return limit, max(1, h*limit/w)
+	}
+	return max(1, w*limit/h), limit
+}
+
+// Resize scales image to w x h by averaging source pixels covered by each target pixel,
+// colors are weighted by alpha so transparent pixels don't darken the result
+func Resize(img image.Image, w, h int) *image.NRGBA {
+	at := rgba(img)
+	b := img.Bounds()
+	res := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	for ty := 0; ty < h; ty++ {
+		y0, y1 := span(ty, h, b.Min.Y, b.Dy())
+		for tx := 0; tx < w; tx++ {
+			x0, x1 := span(tx, w, b.Min.X, b.Dx())
+			var r, g, bl, a float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := at(x, y)
+					alpha := float64(c.A)
+					r += float64(c.R) * alpha
+					g += float64(c.G) * alpha
+					bl += float64(c.B) * alpha
+					a += alpha
+				}
+			}
+
+			var c color.NRGBA
+			if a > 0 {
+				c = color.NRGBA{
+					R: uint8(r/a + 0.5),
+					G: uint8(g/a + 0.5),
+					B: uint8(bl/a + 0.5),
+					A: uint8(a/float64((x1-x0)*(y1-y0)) + 0.5),
+				}
+			}
+			res.SetNRGBA(tx, ty, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+package exif_burst
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+// DefaultMaxGap is enough for bursts of slow cameras and short enough to split separate shots
+const DefaultMaxGap = time.Second
+
+// Detector splits items into bursts.
+// Items with the same BurstUUID always form a burst, other items are joined when they are
+// taken by the same camera and lens one after another within MaxGap.
+// Increasing SequenceNumber is required if both neighbours have it
+type Detector struct {
+	MaxGap        time.Duration
+	MatchExposure bool // exposure time, aperture, ISO and focal length should match too
+}
+
+func NewDetector() *Detector {
+	return &Detector{
+		MaxGap:        DefaultMaxGap,
+		MatchExposure: true,
+	}
+}
+
+type frame struct {
+	item     api.RawItemR
+	index    int // position in input, keeps order stable
+	date     time.Time
+	uuid     string
+	seq      int64
+	camera   string
+	exposure string
+}
+
+var (
+	serialKeys = []string{"SerialNumber", "InternalSerialNumber", "CameraSerialNumber", "BodySerialNumber"}
+	lensKeys   = []string{"LensModel", "LensID", "Lens"}
+)
+
+func first(p api.ExifProvider, keys []string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(p.GetExif(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func newFrame(item api.RawItemR, index int) frame {
+	f := frame{item: item, index: index}
+
+	f.date = item.GetDate()
+	if f.date.IsZero() {
+		f.date, _, _ = api.ExifDateTime(item, "DateTimeOriginal", "SubSecTimeOriginal", "OffsetTimeOriginal", time.UTC)
+	}
+
+	f.uuid = strings.ToLower(strings.TrimSpace(item.GetExif("BurstUUID")))
+	f.seq, _ = api.ExifInt(item, "SequenceNumber")
+
+	f.camera = strings.Join([]string{
+		item.GetExif("Make"), item.GetExif("Model"), first(item, serialKeys), first(item, lensKeys),
+	}, "\x00")
+	f.exposure = strings.Join([]string{
+		item.GetExif("ExposureTime"), item.GetExif("FNumber"), item.GetExif("ISO"), item.GetExif("FocalLength"),
+	}, "\x00")
+	return f
+}
+
+// continues reports whether frame follows previous frame of the same burst
+func (d *Detector) continues(prev, f frame) bool {
+	if prev.camera != f.camera {
+		return false
+	}
+	if d.MatchExposure && prev.exposure != f.exposure {
+		return false
+	}
+	if gap := f.date.Sub(prev.date); gap < 0 || gap > d.MaxGap {
+		return false
+	}
+	if prev.seq > 0 && f.seq > 0 && f.seq <= prev.seq {
+		// sequence restarted, new burst taken right after previous one
+		return false
+	}
+	return true
+}
+
+func compareFrames(a, b frame) int {
+	return cmp.Or(a.date.Compare(b.date), cmp.Compare(a.seq, b.seq), cmp.Compare(a.index, b.index))
+}
+
+// Detect returns groups in order of their first items in input, items of each group are ordered by capture time
+func (d *Detector) Detect(items []api.RawItemR) []*api.Group {
+	var groups [][]frame
+	byUUID := make(map[string]int)
+	var timed []frame
+
+	for i, item := range items {
+		f := newFrame(item, i)
+		switch {
+		case f.uuid != "":
+			if gi, ok := byUUID[f.uuid]; ok {
+				groups[gi] = append(groups[gi], f)
+				continue
+			}
+			byUUID[f.uuid] = len(groups)
+			groups = append(groups, []frame{f})
+		case f.date.IsZero():
+			groups = append(groups, []frame{f})
+		default:
+			timed = append(timed, f)
+		}
+	}
+
+	// frames of different cameras could be interleaved
+	slices.SortFunc(timed, func(a, b frame) int {
+		return cmp.Or(strings.Compare(a.camera, b.camera), compareFrames(a, b))
+	})
+	for i, f := range timed {
+		if i > 0 && d.continues(timed[i-1], f) {
+			groups[len(groups)-1] = append(groups[len(groups)-1], f)
+			continue
+		}
+		groups = append(groups, []frame{f})
+	}
+
+	for _, g := range groups {
+		slices.SortFunc(g, compareFrames)
+	}
+	slices.SortFunc(groups, func(a, b []frame) int {
+		return cmp.Compare(minIndex(a), minIndex(b))
+	})
+
+	res := make([]*api.Group, len(groups))
+	for i, g := range groups {
+		group := &api.Group{ID: groupID(g)}
+		for _, f := range g {
+			group.Items = append(group.Items, f.item)
+		}
+		res[i] = group
+	}
+	return res
+}
+
+func minIndex(g []frame) int {
+	m := g[0].index
+	for _, f := range g[1:] {
+		m = min(m, f.index)
+	}
+	return m
+}
+
+// groupID is stable between runs, it is BurstUUID or hash of the first frame identity
+func groupID(g []frame) string {
+	if g[0].uuid != "" {
+		return "burst:" + g[0].uuid
+	}
+
+	f := g[0]
+	id := f.item.GetGuid()
+	if id == "" {
+		id, _ = api.PathOf(f.item)
+	}
+	if id == "" {
+		id = f.camera + "\x00" + f.date.UTC().Format(time.RFC3339Nano)
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	return "burst:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,113 @@
+package exif_burst
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func exifOf(kv ...string) api.RawExif {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+var base = time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC)
+
+// shot creates item of given camera taken at offset from base time, extra tags override defaults
+func shot(name, serial string, offset time.Duration, extra ...string) *api.RawItem {
+	exif := exifOf(append([]string{
+		"Make", "Canon", "Model", "EOS R5", "SerialNumber", serial, "LensModel", "RF24-70mm F2.8 L IS USM",
+		"ExposureTime", "1/500", "FNumber", "2.8", "ISO", "100", "FocalLength", "50.0 mm",
+	}, extra...)...)
+	item := api.NewRawItem(name, exif)
+	item.SetDate(base.Add(offset))
+	return item
+}
+
+func groupNames(groups []*api.Group) []string {
+	var res []string
+	for _, g := range groups {
+		s := ""
+		for _, item := range g.Items {
+			s += item.(*api.RawItem).GetPath()
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []api.RawItemR
+		expected []string
+	}{
+		{
+			name: "time gap splits bursts",
+			items: []api.RawItemR{
+				shot("a", "1", 0), shot("b", "1", 200*time.Millisecond), shot("c", "1", 400*time.Millisecond),
+				shot("d", "1", 5*time.Second),
+			},
+			expected: []string{"abc", "d"},
+		},
+		{
+			name: "interleaved cameras",
+			items: []api.RawItemR{
+				shot("a", "1", 0), shot("x", "2", 100*time.Millisecond), shot("b", "1", 200*time.Millisecond),
+				shot("y", "2", 300*time.Millisecond),
+			},
+			expected: []string{"ab", "xy"},
+		},
+		{
+			name: "exposure change",
+			items: []api.RawItemR{
+				shot("a", "1", 0), shot("b", "1", 200*time.Millisecond, "ExposureTime", "1/250"),
+			},
+			expected: []string{"a", "b"},
+		},
+		{
+			name: "sequence restart",
+			items: []api.RawItemR{
+				shot("a", "1", 0, "SequenceNumber", "1"), shot("b", "1", 100*time.Millisecond, "SequenceNumber", "2"),
+				shot("c", "1", 200*time.Millisecond, "SequenceNumber", "1"),
+			},
+			expected: []string{"ab", "c"},
+		},
+		{
+			name: "burst uuid and unordered input",
+			items: []api.RawItemR{
+				shot("c", "1", 10*time.Second, "BurstUUID", "U1"), shot("a", "1", 0, "BurstUUID", "U1"),
+				api.NewRawItem("n", nil), shot("b", "1", 3*time.Second, "BurstUUID", "U1"),
+			},
+			expected: []string{"abc", "n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupNames(NewDetector().Detect(tt.items))
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGroupID(t *testing.T) {
+	items := []api.RawItemR{shot("a", "1", 0), shot("b", "1", 100*time.Millisecond)}
+	first := NewDetector().Detect(items)
+	second := NewDetector().Detect(items)
+	if first[0].ID != second[0].ID || first[0].ID == "" {
+		t.Errorf("group id should be stable, got %q and %q", first[0].ID, second[0].ID)
+	}
+
+	uuid := NewDetector().Detect([]api.RawItemR{shot("a", "1", 0, "BurstUUID", "ABC")})
+	if uuid[0].ID != "burst:abc" {
+		t.Errorf("unexpected id %q", uuid[0].ID)
+	}
+}
@@ -0,0 +1,107 @@
+package exif_burst
+
+import (
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_burst"
+	Version = "1"
+)
+
+// TagBurst marks items of groups with more than one item
+const TagBurst = "burst"
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor groups items into bursts, every item gets result with group id, position and size of its group.
+// Items which don't belong to any burst form groups of single item
+type Perceptor struct {
+	Detector *Detector
+}
+
+func New() *Perceptor {
+	return &Perceptor{Detector: NewDetector()}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.ItemGroup }
+
+var (
+	_ api.GroupPerceptor = (*Perceptor)(nil)
+	_ api.Configurable   = (*Perceptor)(nil)
+)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "max_gap",
+			Type:        api.ConfigDuration,
+			Description: "max time between neighbour frames of a burst",
+			Default:     DefaultMaxGap,
+			Min:         api.Limit(0),
+		},
+		{
+			Name:        "match_exposure",
+			Type:        api.ConfigBool,
+			Description: "frames of a burst should have the same exposure time, aperture and ISO",
+			Default:     true,
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	d := NewDetector()
+	d.MaxGap = cfg.Duration("max_gap")
+	d.MatchExposure = cfg.Bool("match_exposure")
+	p.Detector = d
+	return nil
+}
+
+func (p *Perceptor) NewGroupProcessor(chin <-chan api.RawItemR, chout chan<- api.ItemGroupR, logger *l.Logger) chain.Processor {
+	return chain.NewGroupCollector(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Group(items []api.RawItemR) ([]api.ItemGroupR, error) {
+	if err := api.CheckResults(items...); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	groups := pr.perceptor.Detector.Detect(items)
+
+	res := make([]api.ItemGroupR, 0, len(groups))
+	for _, g := range groups {
+		for i, item := range g.Items {
+			result := api.NewResult(pr.perceptor).
+				Set("group_id", api.StringValue(g.ID)).
+				Set("position", api.IntValue(int64(i+1))).
+				Set("size", api.IntValue(int64(len(g.Items))))
+			if len(g.Items) > 1 {
+				result.AddTags(TagBurst)
+			}
+			if err := api.SetResult(item, result); err != nil {
+				return nil, fmt.Errorf("%s: %w", Name, err)
+			}
+		}
+		res = append(res, g)
+	}
+	return res, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,34 @@
+package exif_burst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestGroup(t *testing.T) {
+	pr := &processor{perceptor: New()}
+
+	a, b, c := shot("a", "1", 0), shot("b", "1", 300*time.Millisecond), shot("c", "1", time.Minute)
+	groups, err := pr.Group([]api.RawItemR{b, c, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || len(groups[0].GetItems()) != 2 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+
+	r, _ := b.GetResults().Get(Name)
+	if pos, _ := r.Get("position"); func() int64 { v, _ := pos.AsInt(); return v }() != 2 {
+		t.Errorf("b should be the second frame, got %+v", r)
+	}
+	if len(r.Tags) != 1 || r.Tags[0] != TagBurst {
+		t.Errorf("expected burst tag, got %v", r.Tags)
+	}
+
+	r, _ = c.GetResults().Get(Name)
+	if size, _ := r.Get("size"); func() int64 { v, _ := size.AsInt(); return v }() != 1 || len(r.Tags) != 0 {
+		t.Errorf("c should be a single frame, got %+v", r)
+	}
+}
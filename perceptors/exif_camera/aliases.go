@@ -0,0 +1,295 @@
+package exif_camera
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Aliases maps spellings of makes, models and lenses found in exif to canonical names,
+// it is an editable source of Table
+type Aliases struct {
+	Makes       map[string]string            `json:"makes,omitempty"`        // "NIKON CORPORATION" -> "Nikon"
+	Models      map[string]map[string]string `json:"models,omitempty"`       // canonical make -> "EOS 5D4" -> "EOS 5D Mark IV"
+	Lenses      map[string]string            `json:"lenses,omitempty"`       // "EF24-70mm f/2.8L II USM" -> "Canon EF 24-70mm f/2.8L II USM"
+	CropFactors map[string]float64           `json:"crop_factors,omitempty"` // canonical "Make Model" -> 1.6
+}
+
+// DefaultAliases covers common vendor spellings, popular models and lenses,
+// see LoadAliases to extend it
+var DefaultAliases = Aliases{
+	Makes: map[string]string{
+		"NIKON CORPORATION":           "Nikon",
+		"NIKON":                       "Nikon",
+		"Canon":                       "Canon",
+		"SONY":                        "Sony",
+		"FUJIFILM":                    "Fujifilm",
+		"FUJI PHOTO FILM CO., LTD.":   "Fujifilm",
+		"OLYMPUS IMAGING CORP.":       "Olympus",
+		"OLYMPUS CORPORATION":         "Olympus",
+		"OLYMPUS OPTICAL CO.,LTD":     "Olympus",
+		"OM Digital Solutions":        "OM System",
+		"Panasonic":                   "Panasonic",
+		"PENTAX":                      "Pentax",
+		"PENTAX Corporation":          "Pentax",
+		"RICOH IMAGING COMPANY, LTD.": "Ricoh",
+		"SAMSUNG":                     "Samsung",
+		"LEICA CAMERA AG":             "Leica",
+		"Leica Camera AG":             "Leica",
+		"SIGMA":                       "Sigma",
+		"HUAWEI":                      "Huawei",
+		"EASTMAN KODAK COMPANY":       "Kodak",
+		"KONICA MINOLTA":              "Konica Minolta",
+		"Minolta Co., Ltd.":           "Minolta",
+		"Hasselblad":                  "Hasselblad",
+		"DJI":                         "DJI",
+		"GoPro":                       "GoPro",
+		"Apple":                       "Apple",
+		"Google":                      "Google",
+		"LGE":                         "LG",
+	},
+	Models: map[string]map[string]string{
+		"Canon": {
+			"EOS 5D4":            "EOS 5D Mark IV",
+			"EOS 5D3":            "EOS 5D Mark III",
+			"EOS 5D2":            "EOS 5D Mark II",
+			"EOS 1DX3":           "EOS-1D X Mark III",
+			"EOS Kiss X9i":       "EOS 800D",
+			"EOS Rebel T7i":      "EOS 800D",
+			"EOS DIGITAL REBEL":  "EOS 300D",
+			"EOS Kiss X10":       "EOS 250D",
+			"EOS Rebel SL3":      "EOS 250D",
+			"EOS Kiss M":         "EOS M50",
+			"EOS Rebel T6":       "EOS 1300D",
+			"EOS Kiss X80":       "EOS 1300D",
+			"EOS Kiss Digital X": "EOS 400D",
+			"EOS Rebel XTi":      "EOS 400D",
+		},
+		"Sony": {
+			"ILCE-7M3":  "A7 III",
+			"ILCE-7M4":  "A7 IV",
+			"ILCE-7RM4": "A7R IV",
+			"ILCE-6400": "A6400",
+			"ILCE-6000": "A6000",
+		},
+		"Nikon": {
+			"Z 6_2": "Z 6II",
+			"Z 7_2": "Z 7II",
+		},
+		"Fujifilm": {
+			"FinePix X100":  "X100",
+			"FinePix S5Pro": "FinePix S5 Pro",
+		},
+		"Olympus": {
+			"E-M1MarkII":   "OM-D E-M1 Mark II",
+			"E-M1MarkIII":  "OM-D E-M1 Mark III",
+			"E-M1X":        "OM-D E-M1X",
+			"E-M5":         "OM-D E-M5",
+			"E-M5MarkII":   "OM-D E-M5 Mark II",
+			"E-M5MarkIII":  "OM-D E-M5 Mark III",
+			"E-M10MarkIII": "OM-D E-M10 Mark III",
+			"E-M10MarkIV":  "OM-D E-M10 Mark IV",
+			"E-PL9":        "PEN E-PL9",
+		},
+	},
+	Lenses: map[string]string{
+		"EF24-70mm f/2.8L II USM":              "Canon EF 24-70mm f/2.8L II USM",
+		"EF24-105mm f/4L IS USM":               "Canon EF 24-105mm f/4L IS USM",
+		"EF50mm f/1.8 STM":                     "Canon EF 50mm f/1.8 STM",
+		"EF70-200mm f/2.8L IS II USM":          "Canon EF 70-200mm f/2.8L IS II USM",
+		"EF-S18-55mm f/3.5-5.6 IS STM":         "Canon EF-S 18-55mm f/3.5-5.6 IS STM",
+		"EF-S18-135mm f/3.5-5.6 IS USM":        "Canon EF-S 18-135mm f/3.5-5.6 IS USM",
+		"RF24-105mm F4 L IS USM":               "Canon RF 24-105mm F4 L IS USM",
+		"RF50mm F1.8 STM":                      "Canon RF 50mm F1.8 STM",
+		"FE 24-70mm F2.8 GM":                   "Sony FE 24-70mm F2.8 GM",
+		"FE 28-70mm F3.5-5.6 OSS":              "Sony FE 28-70mm F3.5-5.6 OSS",
+		"FE 85mm F1.8":                         "Sony FE 85mm F1.8",
+		"E 18-135mm F3.5-5.6 OSS":              "Sony E 18-135mm F3.5-5.6 OSS",
+		"NIKKOR Z 24-70mm f/4 S":               "Nikon Nikkor Z 24-70mm f/4 S",
+		"NIKKOR Z 50mm f/1.8 S":                "Nikon Nikkor Z 50mm f/1.8 S",
+		"NIKKOR Z DX 16-50mm f/3.5-6.3 VR":     "Nikon Nikkor Z DX 16-50mm f/3.5-6.3 VR",
+		"AF-S Nikkor 24-70mm f/2.8G ED":        "Nikon AF-S Nikkor 24-70mm f/2.8G ED",
+		"AF-S DX Nikkor 18-55mm f/3.5-5.6G VR": "Nikon AF-S DX Nikkor 18-55mm f/3.5-5.6G VR",
+		"XF35mmF1.4 R":                         "Fujifilm XF 35mm F1.4 R",
+		"XF18-55mmF2.8-4 R LM OIS":             "Fujifilm XF 18-55mm F2.8-4 R LM OIS",
+		"XF16-80mmF4 R OIS WR":                 "Fujifilm XF 16-80mm F4 R OIS WR",
+		"XC15-45mmF3.5-5.6 OIS PZ":             "Fujifilm XC 15-45mm F3.5-5.6 OIS PZ",
+		"OLYMPUS M.12-40mm F2.8":               "Olympus M.Zuiko Digital ED 12-40mm F2.8 Pro",
+		"OLYMPUS M.14-42mm F3.5-5.6 EZ":        "Olympus M.Zuiko Digital ED 14-42mm F3.5-5.6 EZ",
+		"OLYMPUS M.45mm F1.8":                  "Olympus M.Zuiko Digital 45mm F1.8",
+	},
+	CropFactors: map[string]float64{
+		"Canon EOS 800D":              1.6,
+		"Canon EOS 250D":              1.6,
+		"Canon EOS 1300D":             1.6,
+		"Canon EOS 400D":              1.6,
+		"Canon EOS M50":               1.6,
+		"Sony A6400":                  1.5,
+		"Sony A6000":                  1.5,
+		"Nikon D3500":                 1.5,
+		"Nikon D5600":                 1.5,
+		"Nikon D7500":                 1.5,
+		"Nikon Z 50":                  1.5,
+		"Nikon Z fc":                  1.5,
+		"Fujifilm X100":               1.5,
+		"Fujifilm X100F":              1.5,
+		"Fujifilm X100V":              1.5,
+		"Fujifilm X-T3":               1.5,
+		"Fujifilm X-T4":               1.5,
+		"Fujifilm X-T5":               1.5,
+		"Fujifilm X-S10":              1.5,
+		"Fujifilm X-E4":               1.5,
+		"Fujifilm GFX 50S":            0.79,
+		"Fujifilm GFX 100S":           0.79,
+		"Olympus OM-D E-M1 Mark II":   2,
+		"Olympus OM-D E-M1 Mark III":  2,
+		"Olympus OM-D E-M1X":          2,
+		"Olympus OM-D E-M5":           2,
+		"Olympus OM-D E-M5 Mark II":   2,
+		"Olympus OM-D E-M5 Mark III":  2,
+		"Olympus OM-D E-M10 Mark III": 2,
+		"Olympus OM-D E-M10 Mark IV":  2,
+		"Olympus PEN E-PL9":           2,
+		"OM System OM-1":              2,
+		"Canon EOS 5D Mark IV":        1,
+		"Sony A7 III":                 1,
+		"Sony A7 IV":                  1,
+		"Sony A7R IV":                 1,
+		"Nikon D750":                  1,
+		"Nikon D850":                  1,
+		"Nikon Z 6":                   1,
+		"Nikon Z 6II":                 1,
+		"Nikon Z 7II":                 1,
+	},
+}
+
+// normalize returns lookup key of the name
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// Table looks up canonical names, keys are compared case insensitive with collapsed spaces
+type Table struct {
+	makes       map[string]string
+	models      map[string]map[string]string
+	lenses      map[string]string
+	cropFactors map[string]float64
+}
+
+// NewTable builds table from aliases, entries of later aliases replace entries of earlier ones
+func NewTable(aliases ...Aliases) *Table {
+	t := &Table{
+		makes:       make(map[string]string),
+		models:      make(map[string]map[string]string),
+		lenses:      make(map[string]string),
+		cropFactors: make(map[string]float64),
+	}
+	for _, a := range aliases {
+		for k, v := range a.Makes {
+			t.makes[normalize(k)] = v
+		}
+		for mk, models := range a.Models {
+			key := normalize(mk)
+			if t.models[key] == nil {
+				t.models[key] = make(map[string]string)
+			}
+			for k, v := range models {
+				t.models[key][normalize(k)] = v
+			}
+		}
+		for k, v := range a.Lenses {
+			t.lenses[normalize(k)] = v
+		}
+		for k, v := range a.CropFactors {
+			t.cropFactors[normalize(k)] = v
+		}
+	}
+	return t
+}
+
+// LoadAliases reads aliases from json file
+func LoadAliases(path string) (Aliases, error) {
+	var a Aliases
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return a, err
+	}
+	err = json.Unmarshal(data, &a)
+	return a, err
+}
+
+// corporateSuffixes are dropped from unknown makes
+var corporateSuffixes = []string{
+	" corporation", " corp.", " corp", " co., ltd.", " co.,ltd", " co., ltd", " company", " ltd.", " inc.", " ag", " imaging",
+}
+
+// Make returns canonical make, unknown makes lose corporate suffixes and upper case
+func (t *Table) Make(mk string) string {
+	mk = strings.Join(strings.Fields(mk), " ")
+	if v, ok := t.makes[normalize(mk)]; ok {
+		return v
+	}
+
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range corporateSuffixes {
+			if len(mk) > len(suffix) && strings.HasSuffix(strings.ToLower(mk), suffix) {
+				mk = strings.TrimRight(mk[:len(mk)-len(suffix)], " ,")
+				trimmed = true
+			}
+		}
+	}
+	if v, ok := t.makes[normalize(mk)]; ok {
+		return v
+	}
+	if len(mk) > 3 && mk == strings.ToUpper(mk) {
+		mk = titleCase(mk)
+	}
+	return mk
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// Model returns canonical model of canonical make, model loses make prefix written by some vendors,
+// e.g. "Canon EOS 5D Mark IV" or "NIKON D850"
+func (t *Table) Model(mk, rawMake, model string) string {
+	model = strings.Join(strings.Fields(model), " ")
+	prefixes := []string{rawMake, mk}
+	if words := strings.Fields(rawMake); len(words) > 1 {
+		prefixes = append(prefixes, words[0])
+	}
+	for _, prefix := range prefixes {
+		if prefix != "" && len(model) > len(prefix) && strings.EqualFold(model[:len(prefix)], prefix) && model[len(prefix)] == ' ' {
+			model = model[len(prefix)+1:]
+			break
+		}
+	}
+	if v, ok := t.models[normalize(mk)][normalize(model)]; ok {
+		return v
+	}
+	return model
+}
+
+// Lens returns canonical lens
+func (t *Table) Lens(lens string) string {
+	lens = strings.Join(strings.Fields(lens), " ")
+	if v, ok := t.lenses[normalize(lens)]; ok {
+		return v
+	}
+	return lens
+}
+
+// CropFactor returns crop factor of canonical camera "Make Model"
+func (t *Table) CropFactor(camera string) (float64, bool) {
+	v, ok := t.cropFactors[normalize(camera)]
+	return v, ok
+}
@@ -0,0 +1,64 @@
+package exif_camera
+
+import "testing"
+
+func TestTable(t *testing.T) {
+	table := NewTable(DefaultAliases, Aliases{
+		Makes:  map[string]string{"ACME  OPTICS": "Acme"},
+		Models: map[string]map[string]string{"canon": {"eos 5d4": "5D IV"}},
+		Lenses: map[string]string{"EF24-70mm f/2.8L II USM": "Canon EF 24-70mm f/2.8L II USM"},
+	})
+
+	makes := map[string]string{
+		"NIKON CORPORATION":     "Nikon",
+		"nikon  corporation":    "Nikon",
+		"acme optics":           "Acme",
+		"ZEISS CORPORATION":     "Zeiss",
+		"Foo Imaging Co., Ltd.": "Foo",
+		"LEICA CAMERA AG":       "Leica",
+		"OLYMPUS IMAGING CORP.": "Olympus",
+		"HMD Global":            "HMD Global",
+		"":                      "",
+	}
+	for raw, expected := range makes {
+		if got := table.Make(raw); got != expected {
+			t.Errorf("make %q: expected %q, got %q", raw, expected, got)
+		}
+	}
+
+	models := []struct{ mk, raw, model, expected string }{
+		{"Canon", "Canon", "Canon EOS 5D Mark IV", "EOS 5D Mark IV"},
+		{"Canon", "Canon", "EOS 5D4", "5D IV"},
+		{"Nikon", "NIKON CORPORATION", "NIKON D850", "D850"},
+		{"Sony", "SONY", "ILCE-7M3", "A7 III"},
+		{"Apple", "Apple", "iPhone 15 Pro", "iPhone 15 Pro"},
+		{"Canon", "Canon", "Canon", "Canon"},
+		{"Nikon", "NIKON CORPORATION", "NIKON Z 6_2", "Z 6II"},
+		{"Fujifilm", "FUJIFILM", "FinePix X100", "X100"},
+		{"Olympus", "OLYMPUS CORPORATION", "E-M5MarkII", "OM-D E-M5 Mark II"},
+	}
+	for _, tt := range models {
+		if got := table.Model(tt.mk, tt.raw, tt.model); got != tt.expected {
+			t.Errorf("model %q: expected %q, got %q", tt.model, tt.expected, got)
+		}
+	}
+
+	if got := table.Lens("EF24-70mm  f/2.8L II USM"); got != "Canon EF 24-70mm f/2.8L II USM" {
+		t.Errorf("unexpected lens %q", got)
+	}
+	if got := table.Lens("XF35mmF1.4 R"); got != "Fujifilm XF 35mm F1.4 R" {
+		t.Errorf("unexpected default lens %q", got)
+	}
+
+	crops := map[string]float64{
+		"canon eos 800d":            1.6,
+		"Nikon Z 6II":               1,
+		"Fujifilm X-T4":             1.5,
+		"Olympus OM-D E-M5 Mark II": 2,
+	}
+	for camera, expected := range crops {
+		if crop, ok := table.CropFactor(camera); !ok || crop != expected {
+			t.Errorf("%s: expected crop factor %v, got %v", camera, expected, crop)
+		}
+	}
+}
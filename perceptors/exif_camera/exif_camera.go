@@ -0,0 +1,162 @@
+package exif_camera
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_camera"
+	Version = "1"
+)
+
+func init() {
+	api.Register(New())
+}
+
+var lensKeys = []string{"LensModel", "LensID", "Lens", "LensType"}
+
+// Perceptor stores canonical make, model and lens resolved by Table,
+// crop factor and 35mm equivalent focal length. Items without make and model pass unchanged
+type Perceptor struct {
+	Table *Table
+}
+
+func New() *Perceptor {
+	return &Perceptor{Table: NewTable(DefaultAliases)}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+
+var _ api.Configurable = (*Perceptor)(nil)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "aliases",
+			Type:        api.ConfigString,
+			Description: "json file with aliases added over default ones",
+			Default:     "",
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	path := cfg.String("aliases")
+	if path == "" {
+		p.Table = NewTable(DefaultAliases)
+		return nil
+	}
+
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		return &api.ConfigError{Field: "aliases", Err: fmt.Errorf("%w: %w", api.ErrInvalidConfig, err)}
+	}
+	p.Table = NewTable(DefaultAliases, aliases)
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+// Camera is a canonical description of the shooting gear
+type Camera struct {
+	Make       string
+	Model      string
+	Lens       string
+	CropFactor float64 // zero if unknown
+	Focal      float64 // focal length in mm, zero if unknown
+	Focal35    float64 // 35mm equivalent focal length, zero if unknown
+}
+
+// Name returns make and model, e.g. "Canon EOS 5D Mark IV"
+func (c Camera) Name() string {
+	return strings.TrimSpace(c.Make + " " + c.Model)
+}
+
+// Resolve describes camera of the item, false if item has neither make nor model
+func (t *Table) Resolve(p api.ExifProvider) (Camera, bool) {
+	rawMake := strings.TrimSpace(p.GetExif("Make"))
+	rawModel := strings.TrimSpace(p.GetExif("Model"))
+	if rawMake == "" && rawModel == "" {
+		return Camera{}, false
+	}
+
+	var c Camera
+	c.Make = t.Make(rawMake)
+	c.Model = t.Model(c.Make, rawMake, rawModel)
+	for _, key := range lensKeys {
+		if v := strings.TrimSpace(p.GetExif(key)); v != "" && !strings.HasPrefix(v, "Unknown") {
+			c.Lens = t.Lens(v)
+			break
+		}
+	}
+
+	c.Focal, _ = api.ExifFloat(p, "FocalLength")
+	focal35, _ := api.ExifFloat(p, "FocalLengthIn35mmFormat")
+
+	if crop, ok := t.CropFactor(c.Name()); ok {
+		c.CropFactor = crop
+	} else if crop, err := api.ExifFloat(p, "ScaleFactor35efl"); err == nil && crop > 0 {
+		c.CropFactor = crop
+	} else if focal35 > 0 && c.Focal > 0 {
+		c.CropFactor = math.Round(focal35/c.Focal*10) / 10
+	}
+
+	switch {
+	case focal35 > 0:
+		c.Focal35 = focal35
+	case c.Focal > 0 && c.CropFactor > 0:
+		c.Focal35 = math.Round(c.Focal * c.CropFactor)
+	}
+	return c, true
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	c, ok := pr.perceptor.Table.Resolve(item)
+	if !ok {
+		return item, nil
+	}
+
+	result := api.NewResult(pr.perceptor).
+		Set("make", api.StringValue(c.Make)).
+		Set("model", api.StringValue(c.Model)).
+		Set("camera", api.StringValue(c.Name()))
+	if c.Lens != "" {
+		result.Set("lens", api.StringValue(c.Lens))
+	}
+	if c.CropFactor > 0 {
+		result.Set("crop_factor", api.FloatValue(c.CropFactor))
+	}
+	if c.Focal > 0 {
+		result.Set("focal_length", api.FloatValue(c.Focal))
+	}
+	if c.Focal35 > 0 {
+		result.Set("focal_length_35mm", api.FloatValue(c.Focal35))
+	}
+
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,106 @@
+package exif_camera
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func exifOf(kv ...string) api.RawExif {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+func TestResolve(t *testing.T) {
+	table := NewTable(DefaultAliases)
+
+	tests := []struct {
+		name     string
+		exif     api.RawExif
+		expected Camera
+	}{
+		{
+			name:     "crop factor from table",
+			exif:     exifOf("Make", "Canon", "Model", "Canon EOS Rebel T7i", "LensModel", "EF-S18-55mm f/4-5.6 IS STM", "FocalLength", "35.0 mm"),
+			expected: Camera{Make: "Canon", Model: "EOS 800D", Lens: "EF-S18-55mm f/4-5.6 IS STM", CropFactor: 1.6, Focal: 35, Focal35: 56},
+		},
+		{
+			name:     "crop factor from exiftool",
+			exif:     exifOf("Make", "NIKON CORPORATION", "Model", "NIKON D7500", "FocalLength", "50.0 mm", "ScaleFactor35efl", "1.5"),
+			expected: Camera{Make: "Nikon", Model: "D7500", CropFactor: 1.5, Focal: 50, Focal35: 75},
+		},
+		{
+			name:     "35mm focal length",
+			exif:     exifOf("Make", "Apple", "Model", "iPhone 13", "LensModel", "iPhone 13 back dual wide camera 5.1mm f/1.6", "FocalLength", "5.1 mm", "FocalLengthIn35mmFormat", "26 mm"),
+			expected: Camera{Make: "Apple", Model: "iPhone 13", Lens: "iPhone 13 back dual wide camera 5.1mm f/1.6", CropFactor: 5.1, Focal: 5.1, Focal35: 26},
+		},
+		{
+			name:     "unknown lens and focal length",
+			exif:     exifOf("Make", "SONY", "Model", "ILCE-7M3", "LensID", "Unknown (0 0)"),
+			expected: Camera{Make: "Sony", Model: "A7 III", CropFactor: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := table.Resolve(tt.exif)
+			if !ok {
+				t.Fatal("camera should be resolved")
+			}
+			if c != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, c)
+			}
+		})
+	}
+
+	if _, ok := table.Resolve(exifOf("LensModel", "50mm")); ok {
+		t.Error("item without make and model should not be resolved")
+	}
+}
+
+func TestPerceptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	err := os.WriteFile(path, []byte(`{"models": {"Nikon": {"D7500": "D7500 DX"}}, "crop_factors": {"Nikon D7500 DX": 1.53}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New()
+	cfg, err := p.ConfigSchema().Parse(map[string]any{"aliases": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	item := api.NewRawItem("a.nef", exifOf("Make", "NIKON CORPORATION", "Model", "NIKON D7500", "FocalLength", "100.0 mm"))
+	pr := &processor{perceptor: p}
+	if _, err := pr.Decorate(item); err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := item.GetResults().Get(Name)
+	if !ok {
+		t.Fatal("result should be stored")
+	}
+	if v, _ := r.Get("camera"); v != api.StringValue("Nikon D7500 DX") {
+		t.Errorf("unexpected camera %v", v)
+	}
+	if v, _ := r.Get("focal_length_35mm"); v != api.FloatValue(153) {
+		t.Errorf("unexpected equivalent focal length %v", v)
+	}
+	if _, ok := r.Get("lens"); ok {
+		t.Error("lens should not be set")
+	}
+
+	cfg, _ = p.ConfigSchema().Parse(map[string]any{"aliases": filepath.Join(t.TempDir(), "missing.json")})
+	if err := p.Configure(cfg); err == nil {
+		t.Error("missing aliases file should fail")
+	}
+}
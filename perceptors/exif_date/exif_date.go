@@ -0,0 +1,140 @@
+package exif_date
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_date"
+	Version = "1"
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor sets capture date of the item resolved by Resolver
+type Perceptor struct {
+	Resolver *Resolver
+}
+
+func New() *Perceptor {
+	return &Perceptor{Resolver: NewResolver()}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+func (p *Perceptor) Requires() []api.Field              { return nil }
+func (p *Perceptor) Provides() []api.Field              { return []api.Field{api.FieldDate} }
+
+var _ api.Configurable = (*Perceptor)(nil)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	all := make([]string, len(DefaultSources))
+	for i, src := range DefaultSources {
+		all[i] = string(src)
+	}
+
+	return api.ConfigSchema{
+		{
+			Name:        "sources",
+			Type:        api.ConfigStrings,
+			Description: "date sources in priority order",
+			Default:     all,
+			Min:         api.Limit(1),
+			Enum:        all,
+		},
+		{
+			Name:        "infer_zone",
+			Type:        api.ConfigBool,
+			Description: "infer offset of local dates from GPS time",
+			Default:     true,
+		},
+		{
+			Name:        "location",
+			Type:        api.ConfigString,
+			Description: "IANA time zone of dates without offset, local zone if empty",
+			Default:     "",
+		},
+		{
+			Name:        "min_confidence",
+			Type:        api.ConfigFloat,
+			Description: "dates with lower confidence are skipped",
+			Default:     0.0,
+			Min:         api.Limit(0),
+			Max:         api.Limit(1),
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+// Configure replaces Resolver with configured one
+func (p *Perceptor) Configure(cfg api.Config) error {
+	r := NewResolver()
+
+	r.Sources = nil
+	for _, src := range cfg.Strings("sources") {
+		r.Sources = append(r.Sources, Source(src))
+	}
+	r.InferZone = cfg.Bool("infer_zone")
+	r.MinConfidence = cfg.Float("min_confidence")
+
+	if name := cfg.String("location"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return &api.ConfigError{Field: "location", Err: fmt.Errorf("%w: %w", api.ErrInvalidConfig, err)}
+		}
+		r.Location = loc
+	}
+
+	p.Resolver = r
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	if err := api.CheckResults(item); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	editor, ok := api.EditorOf(item, Name)
+	if !ok {
+		return nil, fmt.Errorf("%s: item is read only", Name)
+	}
+
+	res, err := pr.perceptor.Resolver.Resolve(item)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	editor.SetDate(res.Date)
+
+	result := api.NewResult(pr.perceptor)
+	result.Confidence = res.Confidence
+	result.Set("date", api.TimeValue(res.Date)).
+		Set("source", api.StringValue(string(res.Source))).
+		Set("has_zone", api.BoolValue(res.HasZone))
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,91 @@
+package exif_date
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestDecorate(t *testing.T) {
+	pr := &processor{perceptor: New()}
+
+	item := api.NewRawItem("IMG_0001.JPG", exifOf(
+		"DateTimeOriginal", "2024:06:01 10:20:30",
+		"OffsetTimeOriginal", "+03:00",
+	))
+	if _, err := pr.Decorate(item); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC)
+	if !item.GetDate().Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, item.GetDate())
+	}
+	if item.SetBy(api.FieldDate) != Name {
+		t.Error("date should be set on behalf of perceptor")
+	}
+
+	res, ok := item.GetResults().Get(Name)
+	if !ok {
+		t.Fatal("result is not stored")
+	}
+	if src, _ := res.Values["source"].AsString(); src != string(SourceDateTimeOriginal) || res.Confidence != 1 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if _, err := pr.Decorate(api.NewRawItem("DSC_0001.JPG", nil)); !errors.Is(err, ErrNoDate) {
+		t.Errorf("expected ErrNoDate, got %v", err)
+	}
+
+	// editable item without results storage
+	raw := api.NewRawItem("IMG_0002.JPG", exifOf("DateTimeOriginal", "2024:06:01 10:20:30"))
+	noResults := struct {
+		api.RawItemR
+		api.ItemDataEditor
+	}{raw, raw}
+	if _, err := pr.Decorate(noResults); !errors.Is(err, api.ErrNoResults) || !raw.GetDate().IsZero() {
+		t.Errorf("item without results should fail untouched, got %v", err)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	p := New()
+	err := api.ConfigurePerceptors([]api.Perceptor{p}, api.ConfigSet{
+		Name: {"sources": "FileName, FileModifyDate", "location": "UTC", "min_confidence": "0.4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := api.NewRawItem("IMG_20240601_102030.jpg", exifOf(
+		"DateTimeOriginal", "2020:01:01 00:00:00",
+		"FileModifyDate", "2024:07:01 00:00:00+00:00",
+	))
+	res, err := p.Resolver.Resolve(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != SourceFileName || !res.Date.Equal(time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC)) {
+		t.Errorf("unexpected resolution %+v", res)
+	}
+
+	// file modification date is below min confidence
+	if _, err := p.Resolver.Resolve(api.NewRawItem("a.jpg", exifOf("FileModifyDate", "2024:07:01 00:00:00+00:00"))); !errors.Is(err, ErrNoDate) {
+		t.Errorf("expected ErrNoDate, got %v", err)
+	}
+
+	for _, cfg := range []map[string]any{
+		{"sources": "Exif"},
+		{"location": "Mars/Olympus"},
+		{"min_confidence": 2},
+		{"fallback": true},
+	} {
+		err := api.ConfigurePerceptors([]api.Perceptor{p}, api.ConfigSet{Name: cfg})
+		var ce *api.ConfigError
+		if !errors.Is(err, api.ErrInvalidConfig) || !errors.As(err, &ce) || ce.Perceptor != Name {
+			t.Errorf("%v: expected config error of %s, got %v", cfg, Name, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package exif_date
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+var ErrNoDate = errors.New("no capture date found")
+
+// Source identifies where capture date was taken from
+type Source string
+
+const (
+	SourceDateTimeOriginal Source = "DateTimeOriginal"
+	SourceCreateDate       Source = "CreateDate"
+	SourceQuickTime        Source = "QuickTime"
+	SourceGPS              Source = "GPS"
+	SourceFileName         Source = "FileName"
+	SourceFileModifyDate   Source = "FileModifyDate"
+)
+
+// DefaultSources is the default priority of date sources
+var DefaultSources = []Source{
+	SourceDateTimeOriginal,
+	SourceCreateDate,
+	SourceQuickTime,
+	SourceGPS,
+	SourceFileName,
+	SourceFileModifyDate,
+}
+
+// Resolution is a resolved capture date with its source
+type Resolution struct {
+	Date       time.Time
+	Source     Source
+	Confidence float64 // from 0 to 1
+	HasZone    bool    // false if offset is unknown and Resolver.Location was used
+}
+
+// Resolver walks date sources in priority order and takes the first one found
+type Resolver struct {
+	Sources       []Source
+	Location      *time.Location // location for dates without offset, time.Local if nil
+	InferZone     bool           // infer offset of local dates from GPS time
+	MinConfidence float64        // dates with lower confidence are skipped
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		Sources:   DefaultSources,
+		InferZone: true,
+	}
+}
+
+func (r *Resolver) location() *time.Location {
+	if r.Location == nil {
+		return time.Local
+	}
+	return r.Location
+}
+
+// Resolve returns capture date of the item, ErrNoDate if none of sources has it
+func (r *Resolver) Resolve(p api.ExifProvider) (Resolution, error) {
+	for _, src := range r.Sources {
+		res, ok := r.resolve(p, src)
+		if ok && res.Confidence >= r.MinConfidence {
+			res.Source = src
+			return res, nil
+		}
+	}
+	return Resolution{}, ErrNoDate
+}
+
+func (r *Resolver) resolve(p api.ExifProvider, src Source) (Resolution, bool) {
+	switch src {
+	case SourceDateTimeOriginal:
+		if v := p.GetExif("Matroska:DateTimeOriginal"); v != "" && v == p.GetExif("DateTimeOriginal") {
+			// Matroska date is UTC and handled by QuickTime source
+			return Resolution{}, false
+		}
+		return r.local(p, "DateTimeOriginal", "SubSecTimeOriginal", "OffsetTimeOriginal", 1, 0.8)
+	case SourceCreateDate:
+		if api.IsVideo(p) {
+			// QuickTime CreateDate is UTC and handled by its own source
+			return Resolution{}, false
+		}
+		return r.local(p, "CreateDate", "SubSecTimeDigitized", "OffsetTimeDigitized", 0.9, 0.75)
+	case SourceQuickTime:
+		return r.quickTime(p)
+	case SourceGPS:
+		t, ok := gpsTime(p)
+		return Resolution{Date: t, Confidence: 0.7, HasZone: true}, ok
+	case SourceFileName:
+		return r.fileName(p)
+	case SourceFileModifyDate:
+		t, hasZone, err := api.ExifDateTime(p, "FileModifyDate", "", "", r.location())
+		return Resolution{Date: t, Confidence: 0.1, HasZone: hasZone}, err == nil
+	}
+	return Resolution{}, false
+}
+
+// local resolves date stored as local time with optional offset tag
+func (r *Resolver) local(p api.ExifProvider, dateKey, subSecKey, offsetKey string, zoned, naive float64) (Resolution, bool) {
+	t, hasZone, err := api.ExifDateTime(p, dateKey, subSecKey, offsetKey, r.location())
+	if err != nil {
+		return Resolution{}, false
+	}
+	if hasZone {
+		return Resolution{Date: t, Confidence: zoned, HasZone: true}, true
+	}
+
+	if r.InferZone {
+		if zone, ok := inferZone(t, p); ok {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), zone)
+			return Resolution{Date: t, Confidence: zoned - 0.05, HasZone: true}, true
+		}
+	}
+
+	return Resolution{Date: t, Confidence: naive}, true
+}
+
+// maxGPSDrift is the max difference between local time shifted by inferred offset and GPS time
+const maxGPSDrift = 2 * time.Minute
+
+// inferZone finds offset of local time using GPS time, offsets are rounded to 15 minutes
+func inferZone(local time.Time, p api.ExifProvider) (*time.Location, bool) {
+	gps, ok := gpsTime(p)
+	if !ok {
+		return nil, false
+	}
+
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	diff := wall.Sub(gps)
+	offset := diff.Round(15 * time.Minute)
+	if offset.Abs() > 14*time.Hour || (diff-offset).Abs() > maxGPSDrift {
+		return nil, false
+	}
+
+	return time.FixedZone("", int(offset/time.Second)), true
+}
+
+// gpsTime returns UTC time from composite GPSDateTime or GPSDateStamp with GPSTimeStamp
+func gpsTime(p api.ExifProvider) (time.Time, bool) {
+	if t, err := api.ExifTime(p, "GPSDateTime"); err == nil {
+		return t.UTC(), true
+	}
+
+	date, err1 := api.ExifString(p, "GPSDateStamp")
+	tm, err2 := api.ExifString(p, "GPSTimeStamp")
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+
+	t, _, err := api.ParseExifTime(date+" "+tm, time.UTC)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// quickTime resolves creation date of QuickTime and Matroska videos, see api.ExifVideoDate
+func (r *Resolver) quickTime(p api.ExifProvider) (Resolution, bool) {
+	t, local, err := api.ExifVideoDate(p)
+	if err != nil {
+		return Resolution{}, false
+	}
+	if local {
+		// Apple keys store local time with offset
+		return Resolution{Date: t, Confidence: 0.95, HasZone: true}, true
+	}
+	return Resolution{Date: t.In(r.location()), Confidence: 0.85, HasZone: true}, true
+}
+
+var (
+	fileDateTimeRe = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})[-_.]?(\d{2})[-_.]?(\d{2})[-_ T.]?(\d{2})[-_.:h]?(\d{2})[-_.:m]?(\d{2})(?:\d{3})?(?:\D|$)`)
+	fileDateRe     = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})[-_.]?(\d{2})[-_.]?(\d{2})(?:\D|$)`)
+)
+
+func fileName(p api.ExifProvider) string {
+	if name := p.GetExif("FileName"); name != "" {
+		return name
+	}
+	if item, ok := p.(api.RawItemR); ok {
+		if path, ok := api.PathOf(item); ok {
+			return filepath.Base(path)
+		}
+	}
+	return ""
+}
+
+// fileName parses dates from names like IMG_20240601_102030.jpg, PXL_20240601_102030123.jpg,
+// "2024-06-01 10.20.30.jpg" or date only IMG-20240601-WA0001.jpg
+func (r *Resolver) fileName(p api.ExifProvider) (Resolution, bool) {
+	name := fileName(p)
+	if name == "" {
+		return Resolution{}, false
+	}
+
+	if m := fileDateTimeRe.FindStringSubmatch(name); m != nil {
+		value := m[1] + ":" + m[2] + ":" + m[3] + " " + m[4] + ":" + m[5] + ":" + m[6]
+		if t, _, err := api.ParseExifTime(value, r.location()); err == nil {
+			return Resolution{Date: t, Confidence: 0.5}, true
+		}
+	}
+
+	if m := fileDateRe.FindStringSubmatch(name); m != nil {
+		if t, _, err := api.ParseExifTime(m[1]+":"+m[2]+":"+m[3], r.location()); err == nil {
+			return Resolution{Date: t, Confidence: 0.3}, true
+		}
+	}
+
+	return Resolution{}, false
+}
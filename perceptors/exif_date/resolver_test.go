@@ -0,0 +1,212 @@
+package exif_date
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func exifOf(kv ...string) api.RawExif {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+func TestResolve(t *testing.T) {
+	kyiv := time.FixedZone("", 3*3600)
+	r := NewResolver()
+	r.Location = time.UTC
+
+	tests := []struct {
+		name    string
+		exif    api.RawExif
+		date    time.Time
+		source  Source
+		hasZone bool
+		minConf float64
+		maxConf float64
+	}{
+		{
+			name: "original with offset and sub seconds",
+			exif: exifOf(
+				"DateTimeOriginal", "2024:06:01 10:20:30",
+				"SubSecTimeOriginal", "25",
+				"OffsetTimeOriginal", "+03:00",
+				"CreateDate", "2024:06:01 12:00:00",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 250000000, kyiv),
+			source:  SourceDateTimeOriginal,
+			hasZone: true,
+			minConf: 1, maxConf: 1,
+		},
+		{
+			name: "offset inferred from gps",
+			exif: exifOf(
+				"DateTimeOriginal", "2024:06:01 10:20:30",
+				"GPSDateStamp", "2024:06:01",
+				"GPSTimeStamp", "07:20:02",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, kyiv),
+			source:  SourceDateTimeOriginal,
+			hasZone: true,
+			minConf: 0.9, maxConf: 0.99,
+		},
+		{
+			name: "stale gps is ignored",
+			exif: exifOf(
+				"DateTimeOriginal", "2024:06:01 10:20:30",
+				"GPSDateTime", "2024:06:01 07:01:00Z",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC),
+			source:  SourceDateTimeOriginal,
+			minConf: 0.8, maxConf: 0.8,
+		},
+		{
+			name:    "create date",
+			exif:    exifOf("CreateDate", "2024:06:01 10:20:30"),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC),
+			source:  SourceCreateDate,
+			minConf: 0.75, maxConf: 0.75,
+		},
+		{
+			name: "quicktime utc",
+			exif: exifOf(
+				"MIMEType", "video/quicktime",
+				"CreateDate", "2024:06:01 07:20:30",
+			),
+			date:    time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+			source:  SourceQuickTime,
+			hasZone: true,
+			minConf: 0.85, maxConf: 0.85,
+		},
+		{
+			name: "quicktime apple creation date",
+			exif: exifOf(
+				"MIMEType", "video/quicktime",
+				"CreateDate", "2024:06:01 07:20:30",
+				"CreationDate", "2024:06:01 10:20:30+03:00",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, kyiv),
+			source:  SourceQuickTime,
+			hasZone: true,
+			minConf: 0.95, maxConf: 0.95,
+		},
+		{
+			name: "quicktime zero date",
+			exif: exifOf(
+				"MIMEType", "video/mp4",
+				"CreateDate", "0000:00:00 00:00:00",
+				"FileName", "VID_20240601_102030.mp4",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC),
+			source:  SourceFileName,
+			minConf: 0.5, maxConf: 0.5,
+		},
+		{
+			name: "grouped quicktime",
+			exif: exifOf(
+				"MIMEType", "video/mp4",
+				"QuickTime:CreateDate", "2024:06:01 07:20:30",
+				"CreateDate", "2024:06:01 07:20:30",
+				"TrackCreateDate", "0000:00:00 00:00:00",
+			),
+			date:    time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+			source:  SourceQuickTime,
+			hasZone: true,
+			minConf: 0.85, maxConf: 0.85,
+		},
+		{
+			name: "matroska utc",
+			exif: exifOf(
+				"MIMEType", "video/x-matroska",
+				"Matroska:DateTimeOriginal", "2024:06:01 07:20:30",
+				"DateTimeOriginal", "2024:06:01 07:20:30",
+			),
+			date:    time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+			source:  SourceQuickTime,
+			hasZone: true,
+			minConf: 0.85, maxConf: 0.85,
+		},
+		{
+			name:    "gps only",
+			exif:    exifOf("GPSDateTime", "2024:06:01 07:20:30Z"),
+			date:    time.Date(2024, 6, 1, 7, 20, 30, 0, time.UTC),
+			source:  SourceGPS,
+			hasZone: true,
+			minConf: 0.7, maxConf: 0.7,
+		},
+		{
+			name:    "pixel file name",
+			exif:    exifOf("FileName", "PXL_20240601_102030123.jpg"),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC),
+			source:  SourceFileName,
+			minConf: 0.5, maxConf: 0.5,
+		},
+		{
+			name:    "dated file name",
+			exif:    exifOf("FileName", "2024-06-01 10.20.30.jpg"),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, time.UTC),
+			source:  SourceFileName,
+			minConf: 0.5, maxConf: 0.5,
+		},
+		{
+			name:    "whatsapp file name",
+			exif:    exifOf("FileName", "IMG-20240601-WA0001.jpg"),
+			date:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			source:  SourceFileName,
+			minConf: 0.3, maxConf: 0.3,
+		},
+		{
+			name: "file modify date",
+			exif: exifOf(
+				"FileName", "DSC_0001.JPG",
+				"FileModifyDate", "2024:06:01 10:20:30+03:00",
+			),
+			date:    time.Date(2024, 6, 1, 10, 20, 30, 0, kyiv),
+			source:  SourceFileModifyDate,
+			hasZone: true,
+			minConf: 0.1, maxConf: 0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Resolve(tt.exif)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Date.Equal(tt.date) || res.Source != tt.source || res.HasZone != tt.hasZone {
+				t.Errorf("expected %v from %s (zone %v), got %v from %s (zone %v)",
+					tt.date, tt.source, tt.hasZone, res.Date, res.Source, res.HasZone)
+			}
+			if res.Confidence < tt.minConf || res.Confidence > tt.maxConf {
+				t.Errorf("confidence %v is out of [%v, %v]", res.Confidence, tt.minConf, tt.maxConf)
+			}
+		})
+	}
+}
+
+func TestResolveCustomSources(t *testing.T) {
+	r := &Resolver{Sources: []Source{SourceFileName}, Location: time.UTC}
+
+	exif := exifOf(
+		"DateTimeOriginal", "2024:06:01 10:20:30",
+		"FileName", "IMG_20200101_000000.jpg",
+	)
+
+	res, err := r.Resolve(exif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Source != SourceFileName || res.Date.Year() != 2020 {
+		t.Errorf("only file name should be used, got %v from %s", res.Date, res.Source)
+	}
+
+	if _, err := r.Resolve(exifOf("FileName", "DSC_0001.JPG")); !errors.Is(err, ErrNoDate) {
+		t.Errorf("expected ErrNoDate, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package exif_exposure
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+const (
+	TagLongExposure = "long_exposure"
+	TagNight        = "night"
+	TagFlash        = "flash"
+	TagMacro        = "macro"
+	TagTelephoto    = "telephoto"
+	TagWide         = "wide"
+	TagShallowDOF   = "shallow_dof"
+)
+
+// Exposure is a set of shooting parameters, zero values are unknown
+type Exposure struct {
+	ExposureTime float64 // seconds
+	FNumber      float64
+	ISO          float64
+	LightValue   float64 // EV at ISO 100
+	HasLight     bool    // LightValue is known, zero is a valid value
+	Flash        bool
+	Macro        bool    // camera reported macro mode
+	Distance     float64 // subject distance in meters
+	Focal35      float64 // 35mm equivalent focal length
+	CropFactor   float64
+}
+
+// ExifExposure reads shooting parameters, false if none of ExposureTime, FNumber and ISO is present
+func ExifExposure(p api.ExifProvider) (Exposure, bool) {
+	var e Exposure
+	e.ExposureTime, _ = api.ExifFloat(p, "ExposureTime")
+	e.FNumber, _ = api.ExifFloat(p, "FNumber")
+	e.ISO, _ = api.ExifFloat(p, "ISO")
+	if e.ExposureTime <= 0 && e.FNumber <= 0 && e.ISO <= 0 {
+		return Exposure{}, false
+	}
+
+	if lv, err := api.ExifFloat(p, "LightValue"); err == nil {
+		e.LightValue, e.HasLight = lv, true
+	} else if e.ExposureTime > 0 && e.FNumber > 0 && e.ISO > 0 {
+		e.LightValue = math.Log2(e.FNumber*e.FNumber/e.ExposureTime) - math.Log2(e.ISO/100)
+		e.HasLight = true
+	}
+
+	e.Flash = flashFired(p.GetExif("Flash"))
+
+	for _, key := range []string{"MacroMode", "Macro"} {
+		if v := strings.ToLower(strings.TrimSpace(p.GetExif(key))); v != "" && v != "off" && v != "normal" && !strings.HasPrefix(v, "n/a") {
+			e.Macro = true
+			break
+		}
+	}
+	for _, key := range []string{"SubjectDistance", "FocusDistance", "ApproximateFocusDistance"} {
+		if d, err := api.ExifFloat(p, key); err == nil && d > 0 {
+			e.Distance = d
+			break
+		}
+	}
+
+	focal, _ := api.ExifFloat(p, "FocalLength")
+	e.CropFactor, _ = api.ExifFloat(p, "ScaleFactor35efl")
+	if f35, err := api.ExifFloat(p, "FocalLengthIn35mmFormat"); err == nil && f35 > 0 {
+		e.Focal35 = f35
+		if e.CropFactor <= 0 && focal > 0 {
+			e.CropFactor = f35 / focal
+		}
+	} else if focal > 0 && e.CropFactor > 0 {
+		e.Focal35 = focal * e.CropFactor
+	}
+	return e, true
+}
+
+// flashFired parses exiftool Flash values like "On, Fired" or "Off, Did not fire", numeric values keep fired bit
+func flashFired(v string) bool {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return false
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n&1 == 1
+	}
+	return strings.Contains(v, "fired") && !strings.Contains(v, "not fire")
+}
+
+// Thresholds define shooting conditions
+type Thresholds struct {
+	LongExposure   time.Duration // min exposure time
+	NightLight     float64       // max light value of night scenes
+	MacroDistance  float64       // max subject distance in meters
+	TelephotoFocal float64       // min 35mm equivalent focal length
+	WideFocal      float64       // max 35mm equivalent focal length
+	ShallowFNumber float64       // max 35mm equivalent f-number, aperture multiplied by crop factor
+}
+
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		LongExposure:   time.Second,
+		NightLight:     3,
+		MacroDistance:  0.3,
+		TelephotoFocal: 85,
+		WideFocal:      24,
+		ShallowFNumber: 2.8,
+	}
+}
+
+// Classify returns tags of conditions met by exposure, unknown parameters meet none
+func (t Thresholds) Classify(e Exposure) []string {
+	var tags []string
+	if e.ExposureTime > 0 && e.ExposureTime >= t.LongExposure.Seconds() {
+		tags = append(tags, TagLongExposure)
+	}
+	if e.HasLight && e.LightValue <= t.NightLight && !e.Flash {
+		tags = append(tags, TagNight)
+	}
+	if e.Flash {
+		tags = append(tags, TagFlash)
+	}
+	if e.Macro || (e.Distance > 0 && e.Distance <= t.MacroDistance) {
+		tags = append(tags, TagMacro)
+	}
+	if e.Focal35 > 0 && e.Focal35 >= t.TelephotoFocal {
+		tags = append(tags, TagTelephoto)
+	}
+	if e.Focal35 > 0 && e.Focal35 <= t.WideFocal {
+		tags = append(tags, TagWide)
+	}
+	crop := e.CropFactor
+	if crop <= 0 {
+		crop = 1
+	}
+	if e.FNumber > 0 && e.FNumber*crop <= t.ShallowFNumber {
+		tags = append(tags, TagShallowDOF)
+	}
+	return tags
+}
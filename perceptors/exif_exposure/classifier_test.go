@@ -0,0 +1,125 @@
+package exif_exposure
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func exifOf(kv ...string) api.RawExif {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+func TestExifExposure(t *testing.T) {
+	e, ok := ExifExposure(exifOf(
+		"ExposureTime", "1/125",
+		"FNumber", "2.8",
+		"ISO", "400",
+		"Flash", "Off, Did not fire",
+		"FocalLength", "50.0 mm",
+		"ScaleFactor35efl", "1.5",
+		"SubjectDistance", "2.5 m",
+	))
+	if !ok {
+		t.Fatal("exposure should be read")
+	}
+	// log2(2.8^2 * 125) - log2(400/100)
+	if !e.HasLight || math.Abs(e.LightValue-7.94) > 0.01 {
+		t.Errorf("unexpected computed light value %v", e.LightValue)
+	}
+	if e.Flash || e.Focal35 != 75 || e.CropFactor != 1.5 || e.Distance != 2.5 {
+		t.Errorf("unexpected exposure %+v", e)
+	}
+
+	e, _ = ExifExposure(exifOf("FNumber", "1.6", "FocalLength", "5.1 mm", "FocalLengthIn35mmFormat", "26 mm"))
+	if e.HasLight || e.Focal35 != 26 || math.Abs(e.CropFactor-5.1) > 0.01 {
+		t.Errorf("unexpected exposure %+v", e)
+	}
+
+	if _, ok := ExifExposure(exifOf("Make", "Canon", "Flash", "On, Fired")); ok {
+		t.Error("item without exposure should not be read")
+	}
+}
+
+func TestFlashFired(t *testing.T) {
+	tests := map[string]bool{
+		"":                               false,
+		"No Flash":                       false,
+		"Off, Did not fire":              false,
+		"Fired":                          true,
+		"On, Fired":                      true,
+		"Auto, Fired, Red-eye reduction": true,
+		"Auto, Did not fire, Return not detected": false,
+		"16": false,
+		"25": true,
+	}
+	for v, expected := range tests {
+		if got := flashFired(v); got != expected {
+			t.Errorf("%q: expected %v, got %v", v, expected, got)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	th := DefaultThresholds()
+
+	tests := []struct {
+		name     string
+		exif     api.RawExif
+		expected []string
+	}{
+		{
+			name:     "daylight normal",
+			exif:     exifOf("ExposureTime", "1/500", "FNumber", "8.0", "ISO", "100", "FocalLength", "50.0 mm", "FocalLengthIn35mmFormat", "50 mm"),
+			expected: nil,
+		},
+		{
+			name:     "night long exposure",
+			exif:     exifOf("ExposureTime", "30", "FNumber", "4.0", "ISO", "800", "LightValue", "-4.1"),
+			expected: []string{TagLongExposure, TagNight},
+		},
+		{
+			name:     "flash indoors is not night",
+			exif:     exifOf("ExposureTime", "1/60", "FNumber", "4.0", "ISO", "1600", "LightValue", "2.1", "Flash", "On, Fired"),
+			expected: []string{TagFlash},
+		},
+		{
+			name:     "macro mode",
+			exif:     exifOf("ExposureTime", "1/200", "FNumber", "11.0", "ISO", "200", "MacroMode", "Macro", "FocalLength", "100.0 mm", "ScaleFactor35efl", "1.0"),
+			expected: []string{TagMacro, TagTelephoto},
+		},
+		{
+			name:     "close subject",
+			exif:     exifOf("ExposureTime", "1/200", "FNumber", "8.0", "ISO", "200", "SubjectDistance", "0.15 m"),
+			expected: []string{TagMacro},
+		},
+		{
+			name:     "wide phone shot is not shallow",
+			exif:     exifOf("ExposureTime", "1/120", "FNumber", "1.6", "ISO", "50", "FocalLength", "5.1 mm", "FocalLengthIn35mmFormat", "24 mm"),
+			expected: []string{TagWide},
+		},
+		{
+			name:     "fast portrait lens",
+			exif:     exifOf("ExposureTime", "1/250", "FNumber", "1.4", "ISO", "100", "FocalLength", "85.0 mm", "ScaleFactor35efl", "1.0"),
+			expected: []string{TagTelephoto, TagShallowDOF},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := ExifExposure(tt.exif)
+			if !ok {
+				t.Fatal("exposure should be read")
+			}
+			if tags := th.Classify(e); !slices.Equal(tags, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package exif_exposure
+
+import (
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_exposure"
+	Version = "1"
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor stores exposure parameters and tags shooting conditions met by Thresholds,
+// e.g. long_exposure, night or telephoto. Items without exposure data pass unchanged
+type Perceptor struct {
+	Thresholds Thresholds
+}
+
+func New() *Perceptor {
+	return &Perceptor{Thresholds: DefaultThresholds()}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+
+var _ api.Configurable = (*Perceptor)(nil)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	d := DefaultThresholds()
+	return api.ConfigSchema{
+		{
+			Name:        "long_exposure",
+			Type:        api.ConfigDuration,
+			Description: "min exposure time of long exposure",
+			Default:     d.LongExposure,
+			Min:         api.Limit(0),
+		},
+		{
+			Name:        "night_light_value",
+			Type:        api.ConfigFloat,
+			Description: "max light value (EV at ISO 100) of night scenes",
+			Default:     d.NightLight,
+		},
+		{
+			Name:        "macro_distance",
+			Type:        api.ConfigFloat,
+			Description: "max subject distance of macro shots in meters",
+			Default:     d.MacroDistance,
+			Min:         api.Limit(0),
+		},
+		{
+			Name:        "telephoto_focal",
+			Type:        api.ConfigFloat,
+			Description: "min 35mm equivalent focal length of telephoto shots",
+			Default:     d.TelephotoFocal,
+			Min:         api.Limit(0),
+		},
+		{
+			Name:        "wide_focal",
+			Type:        api.ConfigFloat,
+			Description: "max 35mm equivalent focal length of wide angle shots",
+			Default:     d.WideFocal,
+			Min:         api.Limit(0),
+		},
+		{
+			Name:        "shallow_f_number",
+			Type:        api.ConfigFloat,
+			Description: "max 35mm equivalent f-number of shallow depth of field",
+			Default:     d.ShallowFNumber,
+			Min:         api.Limit(0),
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	t := Thresholds{
+		LongExposure:   cfg.Duration("long_exposure"),
+		NightLight:     cfg.Float("night_light_value"),
+		MacroDistance:  cfg.Float("macro_distance"),
+		TelephotoFocal: cfg.Float("telephoto_focal"),
+		WideFocal:      cfg.Float("wide_focal"),
+		ShallowFNumber: cfg.Float("shallow_f_number"),
+	}
+	if t.WideFocal >= t.TelephotoFocal {
+		return &api.ConfigError{Field: "wide_focal", Err: fmt.Errorf("%w: should be less than telephoto_focal", api.ErrInvalidConfig)}
+	}
+	p.Thresholds = t
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	e, ok := ExifExposure(item)
+	if !ok {
+		return item, nil
+	}
+
+	result := api.NewResult(pr.perceptor).
+		Set("flash", api.BoolValue(e.Flash))
+	if e.ExposureTime > 0 {
+		result.Set("exposure_time", api.FloatValue(e.ExposureTime))
+	}
+	if e.FNumber > 0 {
+		result.Set("f_number", api.FloatValue(e.FNumber))
+	}
+	if e.ISO > 0 {
+		result.Set("iso", api.IntValue(int64(e.ISO)))
+	}
+	if e.HasLight {
+		result.Set("light_value", api.FloatValue(e.LightValue))
+	}
+	if e.Focal35 > 0 {
+		result.Set("focal_length_35mm", api.FloatValue(e.Focal35))
+	}
+	result.AddTags(pr.perceptor.Thresholds.Classify(e)...)
+
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,53 @@
+package exif_exposure
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestPerceptor(t *testing.T) {
+	p := New()
+	cfg, err := p.ConfigSchema().Parse(map[string]any{"long_exposure": "1/4s"})
+	if err == nil {
+		t.Fatal("malformed duration should fail")
+	}
+
+	cfg, err = p.ConfigSchema().Parse(map[string]any{"long_exposure": "250ms", "telephoto_focal": 70.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if p.Thresholds.LongExposure != 250*time.Millisecond || p.Thresholds.WideFocal != 24 {
+		t.Errorf("unexpected thresholds %+v", p.Thresholds)
+	}
+
+	item := api.NewRawItem("a.jpg", exifOf("ExposureTime", "0.3", "FNumber", "5.6", "ISO", "100", "FocalLength", "70.0 mm", "FocalLengthIn35mmFormat", "70 mm"))
+	pr := &processor{perceptor: p}
+	if _, err := pr.Decorate(item); err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := item.GetResults().Get(Name)
+	if !ok {
+		t.Fatal("result should be stored")
+	}
+	if v, _ := r.Get("exposure_time"); v != api.FloatValue(0.3) {
+		t.Errorf("unexpected exposure time %v", v)
+	}
+	if v, _ := r.Get("iso"); v != api.IntValue(100) {
+		t.Errorf("unexpected iso %v", v)
+	}
+	if expected := []string{TagLongExposure, TagTelephoto}; !slices.Equal(r.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, r.Tags)
+	}
+
+	cfg, _ = p.ConfigSchema().Parse(map[string]any{"wide_focal": 100.0})
+	if err := p.Configure(cfg); err == nil {
+		t.Error("wide focal over telephoto focal should fail")
+	}
+}
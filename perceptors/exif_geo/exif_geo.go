@@ -0,0 +1,172 @@
+package exif_geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_geo"
+	Version = "1"
+)
+
+// TagSuspicious marks results with (0,0) coordinates, usually written by devices without GPS fix
+const TagSuspicious = "suspicious_location"
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor sets location of the item from GPS tags and resolves its place with Geocoder.
+// Items without GPS pass unchanged, (0,0) coordinates are flagged and not set as location
+type Perceptor struct {
+	Geocoder *Geocoder // place names are not resolved if nil
+}
+
+func New() *Perceptor {
+	return &Perceptor{}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+func (p *Perceptor) Requires() []api.Field              { return nil }
+func (p *Perceptor) Provides() []api.Field              { return []api.Field{api.FieldLocation} }
+
+var _ api.Configurable = (*Perceptor)(nil)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "cities",
+			Type:        api.ConfigString,
+			Description: "GeoNames cities file, e.g. cities1000.txt, place names are not resolved if empty",
+			Default:     "",
+		},
+		{
+			Name:        "regions",
+			Type:        api.ConfigString,
+			Description: "GeoNames admin1CodesASCII.txt",
+			Default:     "",
+		},
+		{
+			Name:        "countries",
+			Type:        api.ConfigString,
+			Description: "GeoNames countryInfo.txt",
+			Default:     "",
+		},
+		{
+			Name:        "max_distance",
+			Type:        api.ConfigFloat,
+			Description: "max distance to the nearest city in km, not limited if zero",
+			Default:     DefaultMaxDistance,
+			Min:         api.Limit(0),
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+// Configure loads dataset, so missing files are reported before processing
+func (p *Perceptor) Configure(cfg api.Config) error {
+	cities := cfg.String("cities")
+	if cities == "" {
+		if cfg.String("regions") != "" || cfg.String("countries") != "" {
+			return &api.ConfigError{Field: "cities", Err: fmt.Errorf("%w: required for place names", api.ErrInvalidConfig)}
+		}
+		p.Geocoder = nil
+		return nil
+	}
+
+	ds, err := LoadDataset(cities, cfg.String("regions"), cfg.String("countries"))
+	if err != nil {
+		return fmt.Errorf("%w: %w", api.ErrInvalidConfig, err)
+	}
+
+	g := NewGeocoder(ds)
+	g.MaxDistance = cfg.Float("max_distance")
+	p.Geocoder = g
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+// IsSuspicious reports coordinates of Null Island
+func IsSuspicious(loc api.Location) bool {
+	const eps = 1e-6
+	return math.Abs(loc.Latitude) < eps && math.Abs(loc.Longitude) < eps
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	loc, err := api.ExifGPS(item)
+	if errors.Is(err, api.ErrExifMissing) {
+		return item, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	if err := api.CheckResults(item); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	result := api.NewResult(pr.perceptor).
+		Set("latitude", api.FloatValue(loc.Latitude)).
+		Set("longitude", api.FloatValue(loc.Longitude)).
+		Set("altitude", api.FloatValue(loc.Altitude))
+
+	if IsSuspicious(loc) {
+		result.Confidence = 0
+		result.AddTags(TagSuspicious)
+		if err := api.SetResult(item, result); err != nil {
+			return nil, fmt.Errorf("%s: %w", Name, err)
+		}
+		return item, nil
+	}
+
+	editor, ok := api.EditorOf(item, Name)
+	if !ok {
+		return nil, fmt.Errorf("%s: item is read only", Name)
+	}
+	me, ok := editor.(api.MetadataDataEditor)
+	if !ok {
+		return nil, fmt.Errorf("%s: item doesn't support location", Name)
+	}
+	me.SetLocation(loc)
+
+	if g := pr.perceptor.Geocoder; g != nil {
+		if place, err := g.Reverse(loc.Latitude, loc.Longitude); err == nil {
+			result.Set("city", api.StringValue(place.City)).
+				Set("region", api.StringValue(place.Region)).
+				Set("country", api.StringValue(place.Country)).
+				Set("country_code", api.StringValue(place.CountryCode)).
+				Set("distance_km", api.FloatValue(place.Distance))
+			for _, name := range []string{place.City, place.Region, place.Country} {
+				if name != "" {
+					result.AddTags(name)
+				}
+			}
+		}
+	}
+
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,95 @@
+package exif_geo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func exifOf(kv ...string) api.RawExif {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return exif
+}
+
+func TestDecorate(t *testing.T) {
+	cities, regions, countries := testDataset(t)
+	p := New()
+	err := api.ConfigurePerceptors([]api.Perceptor{p}, api.ConfigSet{
+		Name: {"cities": cities, "regions": regions, "countries": countries},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr := &processor{perceptor: p}
+
+	t.Run("resolved place", func(t *testing.T) {
+		item := api.NewRawItem("IMG_0001.JPG", exifOf(
+			"GPSLatitude", `43 deg 17' 49.02" N`,
+			"GPSLongitude", `5 deg 22' 51.85" E`,
+			"GPSAltitude", "12 m Above Sea Level",
+		))
+		if _, err := pr.Decorate(item); err != nil {
+			t.Fatal(err)
+		}
+
+		loc, ok := item.GetLocation()
+		if !ok || loc.Altitude != 12 || item.SetBy(api.FieldLocation) != Name {
+			t.Errorf("unexpected location %+v", loc)
+		}
+		r, _ := item.GetResults().Get(Name)
+		city, _ := r.Get("city")
+		if s, _ := city.AsString(); s != "Marseille" {
+			t.Errorf("unexpected result %+v", r)
+		}
+		if len(r.Tags) != 3 || r.Tags[2] != "France" {
+			t.Errorf("unexpected tags %v", r.Tags)
+		}
+	})
+
+	t.Run("null island", func(t *testing.T) {
+		item := api.NewRawItem("IMG_0002.JPG", exifOf("GPSLatitude", "0", "GPSLongitude", "0"))
+		if _, err := pr.Decorate(item); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := item.GetLocation(); ok {
+			t.Error("suspicious location should not be set")
+		}
+		r, _ := item.GetResults().Get(Name)
+		if len(r.Tags) != 1 || r.Tags[0] != TagSuspicious {
+			t.Errorf("expected suspicious flag, got %v", r.Tags)
+		}
+	})
+
+	t.Run("no gps", func(t *testing.T) {
+		item := api.NewRawItem("IMG_0003.JPG", nil)
+		if _, err := pr.Decorate(item); err != nil {
+			t.Errorf("items without gps should pass, got %v", err)
+		}
+	})
+
+	t.Run("no results storage", func(t *testing.T) {
+		raw := api.NewRawItem("IMG_0004.JPG", exifOf("GPSLatitude", "43.3", "GPSLongitude", "5.4"))
+		item := struct {
+			api.RawItemR
+			api.ItemDataEditor
+			api.MetadataDataEditor
+		}{raw, raw, raw}
+		if _, err := pr.Decorate(item); !errors.Is(err, api.ErrNoResults) {
+			t.Errorf("expected ErrNoResults, got %v", err)
+		}
+		if _, ok := raw.GetLocation(); ok {
+			t.Error("failed item should be left untouched")
+		}
+	})
+
+	t.Run("missing dataset", func(t *testing.T) {
+		err := api.ConfigurePerceptors([]api.Perceptor{New()}, api.ConfigSet{Name: {"cities": "/nonexistent"}})
+		if !errors.Is(err, api.ErrInvalidConfig) {
+			t.Errorf("expected ErrInvalidConfig, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package exif_geo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrNoPlace = errors.New("no place within max distance")
+
+// City is a populated place of GeoNames dataset
+type City struct {
+	Name        string
+	CountryCode string // ISO 3166-1 alpha-2
+	Admin1Code  string
+	Latitude    float64
+	Longitude   float64
+	Population  int64
+}
+
+// Place is a result of reverse geocoding
+type Place struct {
+	City        string
+	Region      string // first level administrative division, code if names are not loaded
+	Country     string // country name, code if names are not loaded
+	CountryCode string
+	Distance    float64 // km to the city
+}
+
+// Dataset is a local copy of GeoNames files, see https://download.geonames.org/export/dump/
+type Dataset struct {
+	Cities    []City
+	Regions   map[string]string // "US.CA" -> "California"
+	Countries map[string]string // "US" -> "United States"
+}
+
+// LoadDataset reads GeoNames files, cities file (e.g. cities1000.txt) is required,
+// admin1CodesASCII.txt and countryInfo.txt are optional and could be empty paths
+func LoadDataset(citiesPath, regionsPath, countriesPath string) (*Dataset, error) {
+	ds := &Dataset{}
+
+	err := readFile(citiesPath, func(r io.Reader) (err error) {
+		ds.Cities, err = ParseCities(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if regionsPath != "" {
+		err := readFile(regionsPath, func(r io.Reader) (err error) {
+			ds.Regions, err = parseNames(r, 1)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if countriesPath != "" {
+		err := readFile(countriesPath, func(r io.Reader) (err error) {
+			ds.Countries, err = parseNames(r, 4)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ds, nil
+}
+
+func readFile(path string, fn func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := fn(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// ParseCities reads tab separated GeoNames main table
+func ParseCities(r io.Reader) ([]City, error) {
+	var cities []City
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		cols := strings.Split(line, "\t")
+		if len(cols) < 15 {
+			return nil, fmt.Errorf("line %d: expected at least 15 columns, got %d", n, len(cols))
+		}
+
+		lat, errLat := strconv.ParseFloat(cols[4], 64)
+		lon, errLon := strconv.ParseFloat(cols[5], 64)
+		if errLat != nil || errLon != nil {
+			return nil, fmt.Errorf("line %d: malformed coordinates", n)
+		}
+		population, _ := strconv.ParseInt(cols[14], 10, 64)
+
+		cities = append(cities, City{
+			Name:        cols[1],
+			CountryCode: cols[8],
+			Admin1Code:  cols[10],
+			Latitude:    lat,
+			Longitude:   lon,
+			Population:  population,
+		})
+	}
+	return cities, scanner.Err()
+}
+
+// parseNames reads tab separated code and name, name is taken from given column
+func parseNames(r io.Reader, nameCol int) (map[string]string, error) {
+	names := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) <= nameCol {
+			continue
+		}
+		names[cols[0]] = cols[nameCol]
+	}
+	return names, scanner.Err()
+}
+
+const (
+	earthRadius = 6371.0 // km
+	kmPerDegree = earthRadius * math.Pi / 180
+	cellSize    = 1.0 // degrees
+)
+
+type cell struct {
+	x, y int
+}
+
+const columns = int(360 / cellSize)
+
+// cellOf returns cell of the point, longitude wraps around antimeridian
+func cellOf(lat, lon float64) cell {
+	x := int(math.Floor((lon + 180) / cellSize))
+	return cell{
+		x: ((x % columns) + columns) % columns,
+		y: int(math.Floor((lat + 90) / cellSize)),
+	}
+}
+
+// Geocoder finds nearest city using grid index of cities
+type Geocoder struct {
+	dataset     *Dataset
+	grid        map[cell][]int
+	MaxDistance float64 // km, nearest city is searched everywhere if not positive
+}
+
+// DefaultMaxDistance keeps places of sparsely populated areas without mismatching neighbour countries
+const DefaultMaxDistance = 50.0
+
+func NewGeocoder(ds *Dataset) *Geocoder {
+	g := &Geocoder{
+		dataset:     ds,
+		grid:        make(map[cell][]int),
+		MaxDistance: DefaultMaxDistance,
+	}
+	for i, c := range ds.Cities {
+		key := cellOf(c.Latitude, c.Longitude)
+		g.grid[key] = append(g.grid[key], i)
+	}
+	return g
+}
+
+// Distance returns great circle distance in km
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Reverse returns place of the nearest city
+func (g *Geocoder) Reverse(lat, lon float64) (Place, error) {
+	best, dist := -1, math.Inf(1)
+	check := func(i int) {
+		c := g.dataset.Cities[i]
+		if d := Distance(lat, lon, c.Latitude, c.Longitude); d < dist {
+			best, dist = i, d
+		}
+	}
+
+	if g.MaxDistance <= 0 {
+		for i := range g.dataset.Cities {
+			check(i)
+		}
+	} else {
+		for _, key := range g.cellsAround(lat, lon, g.MaxDistance) {
+			for _, i := range g.grid[key] {
+				check(i)
+			}
+		}
+	}
+
+	if best < 0 || (g.MaxDistance > 0 && dist > g.MaxDistance) {
+		return Place{}, ErrNoPlace
+	}
+	return g.place(g.dataset.Cities[best], dist), nil
+}
+
+// cellsAround returns cells of the box which contains circle with given radius
+func (g *Geocoder) cellsAround(lat, lon, radius float64) []cell {
+	dLat := radius / kmPerDegree
+	dLon := 180.0
+	if cos := math.Cos((math.Abs(lat) + dLat) * math.Pi / 180); cos > 0 {
+		dLon = math.Min(180, dLat/cos)
+	}
+
+	fromX := int(math.Floor((lon - dLon + 180) / cellSize))
+	toX := int(math.Floor((lon + dLon + 180) / cellSize))
+	fromY := cellOf(math.Max(-90, lat-dLat), lon).y
+	toY := cellOf(math.Min(90, lat+dLat), lon).y
+
+	var res []cell
+	for y := fromY; y <= toY; y++ {
+		for x := fromX; x <= toX && x-fromX < columns; x++ {
+			res = append(res, cell{x: ((x % columns) + columns) % columns, y: y})
+		}
+	}
+	return res
+}
+
+func (g *Geocoder) place(c City, dist float64) Place {
+	p := Place{
+		City:        c.Name,
+		Region:      c.Admin1Code,
+		Country:     c.CountryCode,
+		CountryCode: c.CountryCode,
+		Distance:    dist,
+	}
+	if name, ok := g.dataset.Regions[c.CountryCode+"."+c.Admin1Code]; ok {
+		p.Region = name
+	}
+	if name, ok := g.dataset.Countries[c.CountryCode]; ok {
+		p.Country = name
+	}
+	return p
+}
@@ -0,0 +1,74 @@
+package exif_geo
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GeoNames rows, unused columns are empty
+const testCities = "" +
+	"2988507\tParis\tParis\t\t48.85341\t2.3488\tP\tPPLC\tFR\t\t11\t75\t\t\t2138551\t\t42\tEurope/Paris\t2024-01-01\n" +
+	"2995469\tMarseille\tMarseille\t\t43.29695\t5.38107\tP\tPPLA\tFR\t\t93\t13\t\t\t870731\t\t28\tEurope/Paris\t2024-01-01\n" +
+	"2193733\tAuckland\tAuckland\t\t-36.84853\t174.76349\tP\tPPLA\tNZ\t\tE7\t\t\t\t417910\t\t26\tPacific/Auckland\t2024-01-01\n" +
+	"4031574\tSuva\tSuva\t\t-18.14161\t178.44149\tP\tPPLC\tFJ\t\t01\t\t\t\t77366\t\t\tPacific/Fiji\t2024-01-01\n" +
+	"7522232\tLevuka\tLevuka\t\t-17.68\t-179.99\tP\tPPL\tFJ\t\t01\t\t\t\t1000\t\t\tPacific/Fiji\t2024-01-01\n"
+
+func testDataset(t *testing.T) (string, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"cities.txt":    testCities,
+		"admin1.txt":    "FR.11\tÎle-de-France\tIle-de-France\t3012874\nFR.93\tProvence-Alpes-Côte d'Azur\tProvence-Alpes-Cote d'Azur\t2985244\n",
+		"countries.txt": "#ISO\tISO3\tISO-Numeric\tfips\tCountry\n" + "FR\tFRA\t250\tFR\tFrance\tParis\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "cities.txt"), filepath.Join(dir, "admin1.txt"), filepath.Join(dir, "countries.txt")
+}
+
+func TestGeocoder(t *testing.T) {
+	ds, err := LoadDataset(testDataset(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGeocoder(ds)
+
+	place, err := g.Reverse(48.8584, 2.2945) // Eiffel tower
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.City != "Paris" || place.Region != "Île-de-France" || place.Country != "France" || place.CountryCode != "FR" {
+		t.Errorf("unexpected place %+v", place)
+	}
+	if math.Abs(place.Distance-4.2) > 0.5 {
+		t.Errorf("unexpected distance %v", place.Distance)
+	}
+
+	// across antimeridian
+	place, err = g.Reverse(-17.7, 179.95)
+	if err != nil || place.City != "Levuka" {
+		t.Errorf("expected Levuka, got %+v, %v", place, err)
+	}
+
+	if _, err := g.Reverse(0, -30); !errors.Is(err, ErrNoPlace) {
+		t.Errorf("expected ErrNoPlace in the ocean, got %v", err)
+	}
+
+	g.MaxDistance = 0
+	if place, err := g.Reverse(0, -30); err != nil || place.CountryCode == "" {
+		t.Errorf("unlimited search should find something, got %+v, %v", place, err)
+	}
+}
+
+func TestParseCities(t *testing.T) {
+	if _, err := ParseCities(strings.NewReader("1\tParis\t48.8\n")); err == nil {
+		t.Error("expected error of short line")
+	}
+}
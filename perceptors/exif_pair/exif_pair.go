@@ -0,0 +1,166 @@
+package exif_pair
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_pair"
+	Version = "1"
+)
+
+const (
+	TagRawJPEG     = "raw+jpeg"
+	TagLivePhoto   = "live_photo"
+	TagMotionPhoto = "motion_photo"
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor groups related files into logical items, see Pairer.
+// Every group holds primary item first and items of its companions after it,
+// companions are attached to the primary item with api.CompanionEditor.
+// Primary items get result with roles and paths of companions, companion items get path of their primary
+type Perceptor struct {
+	Pairer *Pairer
+}
+
+func New() *Perceptor {
+	return &Perceptor{Pairer: NewPairer()}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.ItemGroup }
+func (p *Perceptor) Requires() []api.Field              { return nil }
+func (p *Perceptor) Provides() []api.Field              { return []api.Field{api.FieldCompanions} }
+
+var (
+	_ api.GroupPerceptor = (*Perceptor)(nil)
+	_ api.Configurable   = (*Perceptor)(nil)
+)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "primary",
+			Type:        api.ConfigString,
+			Description: "primary file of RAW+JPEG pair",
+			Default:     "raw",
+			Enum:        []string{"raw", "jpeg"},
+		},
+		{
+			Name:        "raw_extensions",
+			Type:        api.ConfigStrings,
+			Description: "extensions of raw files, lower case with dot",
+			Default:     DefaultRawExtensions,
+		},
+		{
+			Name:        "video_extensions",
+			Type:        api.ConfigStrings,
+			Description: "extensions of Live Photo videos, lower case with dot",
+			Default:     DefaultVideoExtensions,
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	pr := NewPairer()
+	pr.RawPrimary = cfg.String("primary") == "raw"
+	pr.RawExtensions = cfg.Strings("raw_extensions")
+	pr.VideoExtensions = cfg.Strings("video_extensions")
+	p.Pairer = pr
+	return nil
+}
+
+func (p *Perceptor) NewGroupProcessor(chin <-chan api.RawItemR, chout chan<- api.ItemGroupR, logger *l.Logger) chain.Processor {
+	return chain.NewGroupCollector(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Group(items []api.RawItemR) ([]api.ItemGroupR, error) {
+	pairs := pr.perceptor.Pairer.Pair(items)
+	for _, pair := range pairs {
+		if len(pair.Companions) == 0 {
+			continue
+		}
+		if err := api.CheckResults(pair.Items()...); err != nil {
+			primary, _ := api.PathOf(pair.Primary)
+			return nil, fmt.Errorf("%s: %s: %w", Name, primary, err)
+		}
+	}
+
+	res := make([]api.ItemGroupR, 0, len(pairs))
+	for _, pair := range pairs {
+		primary, _ := api.PathOf(pair.Primary)
+
+		if len(pair.Companions) > 0 {
+			if err := pr.attach(pair); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", Name, primary, err)
+			}
+		}
+
+		res = append(res, &api.Group{ID: "pair:" + primary, Items: pair.Items()})
+	}
+	return res, nil
+}
+
+func (pr *processor) attach(pair *Pair) error {
+	editor, ok := api.EditorOf(pair.Primary, Name)
+	if !ok {
+		return errors.New("item is read only")
+	}
+	ce, ok := editor.(api.CompanionEditor)
+	if !ok {
+		return errors.New("item doesn't support companions")
+	}
+
+	primary, _ := api.PathOf(pair.Primary)
+	result := api.NewResult(pr.perceptor)
+	var roles, paths []string
+	for _, c := range pair.Companions {
+		ce.AddCompanion(c)
+		roles = append(roles, string(c.Role))
+		paths = append(paths, c.Path)
+
+		switch c.Role {
+		case api.CompanionRaw, api.CompanionJPEG:
+			result.AddTags(TagRawJPEG)
+		case api.CompanionLivePhoto:
+			result.AddTags(TagLivePhoto)
+		case api.CompanionMotionPhoto:
+			result.AddTags(TagMotionPhoto)
+		}
+
+		if c.Item != nil {
+			cr := api.NewResult(pr.perceptor).
+				Set("role", api.StringValue(string(c.Role))).
+				Set("primary", api.StringValue(primary))
+			if err := api.SetResult(c.Item, cr); err != nil {
+				return fmt.Errorf("%s: %w", c.Path, err)
+			}
+		}
+	}
+	result.Set("role", api.StringValue("primary")).
+		Set("companion_roles", api.StringsValue(roles)).
+		Set("companions", api.StringsValue(paths))
+	return api.SetResult(pair.Primary, result)
+}
+
+func (pr *processor) Stop() {}
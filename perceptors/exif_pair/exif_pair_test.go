@@ -0,0 +1,64 @@
+package exif_pair
+
+import (
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestGroup(t *testing.T) {
+	pr := &processor{perceptor: New()}
+
+	jpg, raw, single := item("d/IMG_1.JPG"), item("d/IMG_1.CR2"), item("d/IMG_2.JPG")
+	groups, err := pr.Group([]api.RawItemR{jpg, raw, single})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if items := groups[0].GetItems(); len(items) != 2 || items[0] != raw || items[1] != jpg {
+		t.Errorf("raw should be primary, got %v", items)
+	}
+	if groups[0].GetGroupID() != "pair:d/IMG_1.CR2" {
+		t.Errorf("unexpected group id %q", groups[0].GetGroupID())
+	}
+
+	companions := api.CompanionsOf(raw)
+	if len(companions) != 1 || companions[0].Item != jpg || companions[0].Role != api.CompanionJPEG {
+		t.Errorf("unexpected companions %+v", companions)
+	}
+	if raw.SetBy(api.FieldCompanions) != Name {
+		t.Errorf("companions should be set by %s", Name)
+	}
+
+	r, ok := raw.GetResults().Get(Name)
+	if !ok || len(r.Tags) != 1 || r.Tags[0] != TagRawJPEG {
+		t.Errorf("unexpected primary result %+v", r)
+	}
+	r, ok = jpg.GetResults().Get(Name)
+	if v, _ := r.Get("primary"); !ok || v != api.StringValue("d/IMG_1.CR2") {
+		t.Errorf("unexpected companion result %+v", r)
+	}
+	if _, ok := single.GetResults().Get(Name); ok || len(api.CompanionsOf(single)) != 0 {
+		t.Error("single item should stay untouched")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	p := New()
+	cfg, err := p.ConfigSchema().Parse(map[string]any{"primary": "jpeg", "raw_extensions": []any{".raw"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if p.Pairer.RawPrimary || len(p.Pairer.RawExtensions) != 1 || p.Pairer.RawExtensions[0] != ".raw" {
+		t.Errorf("unexpected pairer %+v", p.Pairer)
+	}
+
+	if _, err := p.ConfigSchema().Parse(map[string]any{"primary": "heic"}); err == nil {
+		t.Error("unknown primary should fail")
+	}
+}
@@ -0,0 +1,230 @@
+package exif_pair
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+var (
+	DefaultRawExtensions = []string{
+		".3fr", ".arw", ".cr2", ".cr3", ".crw", ".dng", ".erf", ".iiq", ".mrw", ".nef",
+		".nrw", ".orf", ".pef", ".raf", ".rw2", ".rwl", ".sr2", ".srf", ".srw", ".x3f",
+	}
+	DefaultJPEGExtensions  = []string{".jpg", ".jpeg"}
+	DefaultVideoExtensions = []string{".mov", ".mp4"}
+)
+
+// contentIDKeys link Live Photo image with its video, newer exiftool names image tag MediaGroupUUID
+var contentIDKeys = []string{"ContentIdentifier", "MediaGroupUUID"}
+
+// Pair is a logical item, primary file with its companions
+type Pair struct {
+	Primary    api.RawItemR
+	Companions []api.Companion
+}
+
+// Items returns primary item followed by items of its companions, embedded companions have no items
+func (p *Pair) Items() []api.RawItemR {
+	items := []api.RawItemR{p.Primary}
+	for _, c := range p.Companions {
+		if c.Item != nil {
+			items = append(items, c.Item)
+		}
+	}
+	return items
+}
+
+// Pairer finds related files of the same shot:
+// raw and jpeg files with the same name in the same directory,
+// Live Photo images and videos with the same ContentIdentifier
+// and Motion Photos with video embedded into the image
+type Pairer struct {
+	RawExtensions   []string
+	JPEGExtensions  []string
+	VideoExtensions []string
+	RawPrimary      bool // raw file is primary item of RAW+JPEG pair, jpeg otherwise
+}
+
+func NewPairer() *Pairer {
+	return &Pairer{
+		RawExtensions:   DefaultRawExtensions,
+		JPEGExtensions:  DefaultJPEGExtensions,
+		VideoExtensions: DefaultVideoExtensions,
+		RawPrimary:      true,
+	}
+}
+
+func (pr *Pairer) isVideo(item api.RawItemR, ext string) bool {
+	return slices.Contains(pr.VideoExtensions, ext) || strings.HasPrefix(item.GetExif("MIMEType"), "video/")
+}
+
+// Pair groups items into logical items, every item belongs to exactly one pair.
+// Pairs are ordered by position of their first item in input
+func (pr *Pairer) Pair(items []api.RawItemR) []*Pair {
+	n := len(items)
+	paths := make([]string, n)
+	exts := make([]string, n)
+	for i, item := range items {
+		paths[i], _ = api.PathOf(item)
+		exts[i] = strings.ToLower(filepath.Ext(paths[i]))
+	}
+
+	// owner is an index of primary item, companions point to their primary
+	owner := make([]int, n)
+	for i := range owner {
+		owner[i] = i
+	}
+	companions := make([][]api.Companion, n)
+	attach := func(primary, i int, role api.CompanionRole) {
+		owner[i] = primary
+		companions[primary] = append(companions[primary], api.Companion{Role: role, Path: paths[i], Item: items[i]})
+	}
+
+	// RAW+JPEG by name
+	type stem struct {
+		raws, jpegs []int
+	}
+	stems := make(map[string]*stem)
+	var order []string
+	for i := range items {
+		isRaw := slices.Contains(pr.RawExtensions, exts[i])
+		isJPEG := slices.Contains(pr.JPEGExtensions, exts[i])
+		if !isRaw && !isJPEG {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSuffix(paths[i], filepath.Ext(paths[i])))
+		s, ok := stems[key]
+		if !ok {
+			s = &stem{}
+			stems[key] = s
+			order = append(order, key)
+		}
+		if isRaw {
+			s.raws = append(s.raws, i)
+		} else {
+			s.jpegs = append(s.jpegs, i)
+		}
+	}
+	for _, key := range order {
+		s := stems[key]
+		if len(s.raws) == 0 || len(s.jpegs) == 0 {
+			continue
+		}
+		first, second := s.raws, s.jpegs
+		if !pr.RawPrimary {
+			first, second = s.jpegs, s.raws
+		}
+		primary := first[0]
+		for _, i := range first[1:] {
+			attach(primary, i, roleOf(pr.RawPrimary))
+		}
+		for _, i := range second {
+			attach(primary, i, roleOf(!pr.RawPrimary))
+		}
+	}
+
+	// Live Photo by content identifier, video goes to the pair of its image
+	videos := make(map[string][]int)
+	var images []int
+	for i, item := range items {
+		id := contentID(item)
+		if id == "" {
+			continue
+		}
+		if pr.isVideo(item, exts[i]) {
+			videos[id] = append(videos[id], i)
+		} else {
+			images = append(images, i)
+		}
+	}
+	for _, i := range images {
+		id := contentID(items[i])
+		for _, v := range videos[id] {
+			if owner[v] == v && len(companions[v]) == 0 {
+				attach(owner[i], v, api.CompanionLivePhoto)
+			}
+		}
+	}
+
+	// Motion Photo video is a part of the image file
+	for i, item := range items {
+		if pr.isVideo(item, exts[i]) {
+			continue
+		}
+		if length, ok := motionPhoto(item); ok {
+			companions[owner[i]] = append(companions[owner[i]], api.Companion{
+				Role:     api.CompanionMotionPhoto,
+				Path:     paths[i],
+				Embedded: true,
+				Length:   length,
+			})
+		}
+	}
+
+	var pairs []*Pair
+	for i, item := range items {
+		if owner[i] == i {
+			pairs = append(pairs, &Pair{Primary: item, Companions: companions[i]})
+		}
+	}
+	return pairs
+}
+
+func roleOf(raw bool) api.CompanionRole {
+	if raw {
+		return api.CompanionRaw
+	}
+	return api.CompanionJPEG
+}
+
+func contentID(item api.RawItemR) string {
+	for _, key := range contentIDKeys {
+		if v := strings.TrimSpace(item.GetExif(key)); v != "" {
+			return strings.ToUpper(v)
+		}
+	}
+	return ""
+}
+
+var binaryLength = regexp.MustCompile(`Binary data (\d+) bytes`)
+
+// motionPhoto detects video embedded into the image and returns its length if known.
+// Google Motion Photo marks it in XMP, older Micro Video keeps offset of the video from the end of file,
+// Samsung puts it into trailer
+func motionPhoto(item api.RawItemR) (int64, bool) {
+	if semantic, err := api.ExifList(item, "DirectoryItemSemantic"); err == nil {
+		if i := slices.Index(semantic, "MotionPhoto"); i >= 0 {
+			var length int64
+			if lengths, err := api.ExifList(item, "DirectoryItemLength"); err == nil && i < len(lengths) {
+				length, _ = strconv.ParseInt(lengths[i], 10, 64)
+			}
+			return length, true
+		}
+	}
+
+	if ok, _ := api.ExifBool(item, "MotionPhoto"); ok {
+		return 0, true
+	}
+
+	if ok, _ := api.ExifBool(item, "MicroVideo"); ok {
+		length, _ := api.ExifInt(item, "MicroVideoOffset")
+		return length, true
+	}
+
+	if strings.HasPrefix(item.GetExif("EmbeddedVideoType"), "MotionPhoto") || item.GetExif("MotionPhotoVideo") != "" {
+		var length int64
+		for _, key := range []string{"EmbeddedVideoFile", "MotionPhotoVideo"} {
+			if m := binaryLength.FindStringSubmatch(item.GetExif(key)); m != nil {
+				length, _ = strconv.ParseInt(m[1], 10, 64)
+				break
+			}
+		}
+		return length, true
+	}
+	return 0, false
+}
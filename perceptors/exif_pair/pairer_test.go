@@ -0,0 +1,105 @@
+package exif_pair
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func item(path string, kv ...string) *api.RawItem {
+	exif := make(api.RawExif)
+	for i := 0; i+1 < len(kv); i += 2 {
+		exif[kv[i]] = []byte(kv[i+1])
+	}
+	return api.NewRawItem(path, exif)
+}
+
+// describe renders pairs as "primary[role:path ...]"
+func describe(pairs []*Pair) []string {
+	var res []string
+	for _, p := range pairs {
+		s, _ := api.PathOf(p.Primary)
+		if len(p.Companions) > 0 {
+			s += "["
+			for i, c := range p.Companions {
+				if i > 0 {
+					s += " "
+				}
+				s += fmt.Sprintf("%s:%s", c.Role, c.Path)
+				if c.Embedded {
+					s += fmt.Sprintf("@%d", c.Length)
+				}
+			}
+			s += "]"
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func TestPair(t *testing.T) {
+	tests := []struct {
+		name     string
+		jpeg     bool
+		items    []api.RawItemR
+		expected []string
+	}{
+		{
+			name: "raw and jpeg",
+			items: []api.RawItemR{
+				item("d/IMG_1.JPG"), item("d/IMG_1.CR2"), item("d/IMG_2.jpg"), item("e/IMG_2.nef"), item("d/IMG_3.ARW"), item("d/img_3.jpeg"),
+			},
+			expected: []string{"d/IMG_1.CR2[jpeg:d/IMG_1.JPG]", "d/IMG_2.jpg", "e/IMG_2.nef", "d/IMG_3.ARW[jpeg:d/img_3.jpeg]"},
+		},
+		{
+			name:     "jpeg primary",
+			jpeg:     true,
+			items:    []api.RawItemR{item("d/IMG_1.NEF"), item("d/IMG_1.JPG")},
+			expected: []string{"d/IMG_1.JPG[raw:d/IMG_1.NEF]"},
+		},
+		{
+			name: "live photo",
+			items: []api.RawItemR{
+				item("d/IMG_5.MOV", "ContentIdentifier", "ab-12"),
+				item("d/IMG_5.HEIC", "ContentIdentifier", "AB-12"),
+				item("d/IMG_6.HEIC", "MediaGroupUUID", "CD-34"),
+				item("x/clip.mp4", "ContentIdentifier", "CD-34"),
+				item("d/IMG_7.MOV", "ContentIdentifier", "EF-56"),
+			},
+			expected: []string{"d/IMG_5.HEIC[live_photo:d/IMG_5.MOV]", "d/IMG_6.HEIC[live_photo:x/clip.mp4]", "d/IMG_7.MOV"},
+		},
+		{
+			name: "motion photo",
+			items: []api.RawItemR{
+				item("a.jpg", "DirectoryItemSemantic", "Primary, MotionPhoto", "DirectoryItemLength", "0, 4096"),
+				item("b.jpg", "MicroVideo", "1", "MicroVideoOffset", "2048"),
+				item("c.jpg", "EmbeddedVideoType", "MotionPhoto_Data", "EmbeddedVideoFile", "(Binary data 1024 bytes, use -b option to extract)"),
+				item("d.jpg", "MotionPhoto", "1"),
+				item("e.jpg", "MicroVideo", "0"),
+			},
+			expected: []string{
+				"a.jpg[motion_photo:a.jpg@4096]", "b.jpg[motion_photo:b.jpg@2048]", "c.jpg[motion_photo:c.jpg@1024]",
+				"d.jpg[motion_photo:d.jpg@0]", "e.jpg",
+			},
+		},
+		{
+			name: "motion photo companion of raw",
+			items: []api.RawItemR{
+				item("P1.dng"), item("P1.jpg", "MotionPhoto", "1"),
+			},
+			expected: []string{"P1.dng[jpeg:P1.jpg motion_photo:P1.jpg@0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := NewPairer()
+			pr.RawPrimary = !tt.jpeg
+			got := describe(pr.Pair(tt.items))
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
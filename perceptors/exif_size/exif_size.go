@@ -0,0 +1,143 @@
+package exif_size
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "exif_size"
+	Version = "1"
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor sets stored size and ratio of the item from exif,
+// displayed variants are available through api.OrientedDataProvider
+type Perceptor struct {
+	// Classifier snaps ratio to the nearest standard one within its tolerance,
+	// exact ratio is set if nil or nothing is close enough
+	Classifier *api.RatioClassifier
+}
+
+func New() *Perceptor {
+	return &Perceptor{}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+func (p *Perceptor) Requires() []api.Field              { return nil }
+func (p *Perceptor) Provides() []api.Field              { return []api.Field{api.FieldSize, api.FieldRatio} }
+
+var _ api.Configurable = (*Perceptor)(nil)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	ratios := make([]string, len(api.StandardRatios))
+	for i, r := range api.StandardRatios {
+		ratios[i] = fmt.Sprintf("%d:%d", r.W, r.H)
+	}
+
+	return api.ConfigSchema{
+		{
+			Name:        "snap_ratio",
+			Type:        api.ConfigBool,
+			Description: "snap ratio to the nearest standard one",
+			Default:     false,
+		},
+		{
+			Name:        "ratio_tolerance",
+			Type:        api.ConfigFloat,
+			Description: "max relative deviation from standard ratio",
+			Default:     api.DefaultRatioTolerance,
+			Min:         api.Limit(0),
+			Max:         api.Limit(0.5),
+		},
+		{
+			Name:        "ratios",
+			Type:        api.ConfigStrings,
+			Description: "standard ratios like 16:9",
+			Default:     ratios,
+			Min:         api.Limit(1),
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	if !cfg.Bool("snap_ratio") {
+		p.Classifier = nil
+		return nil
+	}
+
+	c := api.NewRatioClassifier()
+	c.Tolerance = cfg.Float("ratio_tolerance")
+	c.Ratios = nil
+	for _, s := range cfg.Strings("ratios") {
+		r, err := parseRatio(s)
+		if err != nil {
+			return &api.ConfigError{Field: "ratios", Err: err}
+		}
+		c.Ratios = append(c.Ratios, r)
+	}
+
+	p.Classifier = c
+	return nil
+}
+
+func parseRatio(s string) (api.Size, error) {
+	ws, hs, ok := strings.Cut(s, ":")
+	w, errW := strconv.Atoi(ws)
+	h, errH := strconv.Atoi(hs)
+	if !ok || errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return api.Size{}, fmt.Errorf("%w: ratio %q is not like 16:9", api.ErrInvalidConfig, s)
+	}
+	return api.Size{W: w, H: h}, nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	editor, ok := api.EditorOf(item, Name)
+	if !ok {
+		return nil, fmt.Errorf("%s: item is read only", Name)
+	}
+
+	size, err := api.ExifSize(item)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	editor.SetSize(size)
+	editor.SetRatio(pr.perceptor.ratio(size))
+	return item, nil
+}
+
+func (p *Perceptor) ratio(size api.Size) api.Size {
+	if p.Classifier != nil {
+		if r, _, err := p.Classifier.Classify(size); err == nil {
+			return r
+		}
+	}
+	return api.GetRatio(size)
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,87 @@
+package exif_size
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestDecorate(t *testing.T) {
+	pr := &processor{perceptor: New()}
+
+	t.Run("rotated image", func(t *testing.T) {
+		item := api.NewRawItem("IMG_0001.JPG", api.RawExif{
+			"ImageWidth":  []byte("6000"),
+			"ImageHeight": []byte("4000"),
+			"Orientation": []byte("Rotate 270 CW"),
+		})
+
+		if _, err := pr.Decorate(item); err != nil {
+			t.Fatal(err)
+		}
+
+		if item.GetSize() != (api.Size{W: 6000, H: 4000}) || item.GetRatio() != (api.Size{W: 3, H: 2}) {
+			t.Errorf("unexpected stored size %v and ratio %v", item.GetSize(), item.GetRatio())
+		}
+		if item.GetDisplaySize() != (api.Size{W: 4000, H: 6000}) || item.GetDisplayRatio() != (api.Size{W: 2, H: 3}) {
+			t.Errorf("unexpected display size %v and ratio %v", item.GetDisplaySize(), item.GetDisplayRatio())
+		}
+		if item.SetBy(api.FieldSize) != Name {
+			t.Error("size should be set on behalf of perceptor")
+		}
+	})
+
+	t.Run("video", func(t *testing.T) {
+		item, err := api.ParseRawItem([]byte("======== VID_0001.MP4\n" +
+			"[File]      MIMEType    : video/mp4\n" +
+			"[QuickTime] ImageWidth  : 3840\n" +
+			"[QuickTime] ImageHeight : 2160\n" +
+			"[Composite] Rotation    : 270\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := pr.Decorate(item); err != nil {
+			t.Fatal(err)
+		}
+		if item.GetSize() != (api.Size{W: 3840, H: 2160}) || item.GetDisplaySize() != (api.Size{W: 2160, H: 3840}) {
+			t.Errorf("unexpected size %v and display size %v", item.GetSize(), item.GetDisplaySize())
+		}
+	})
+
+	t.Run("missing size", func(t *testing.T) {
+		_, err := pr.Decorate(api.NewRawItem("IMG_0002.JPG", nil))
+		if !errors.Is(err, api.ErrExifMissing) {
+			t.Errorf("expected ErrExifMissing, got %v", err)
+		}
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	p := New()
+	err := api.ConfigurePerceptors([]api.Perceptor{p}, api.ConfigSet{
+		Name: {"snap_ratio": "true", "ratio_tolerance": 0.02, "ratios": []any{"3:2", "16:9"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := api.NewRawItem("IMG_0001.JPG", api.RawExif{
+		"ImageWidth":  []byte("1920"),
+		"ImageHeight": []byte("1088"),
+	})
+	if _, err := (&processor{perceptor: p}).Decorate(item); err != nil {
+		t.Fatal(err)
+	}
+	if item.GetRatio() != (api.Size{W: 16, H: 9}) {
+		t.Errorf("expected snapped ratio 16:9, got %v", item.GetRatio())
+	}
+
+	err = api.ConfigurePerceptors([]api.Perceptor{p}, api.ConfigSet{
+		Name: {"snap_ratio": true, "ratios": []any{"16x9"}},
+	})
+	if !errors.Is(err, api.ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package item_guid
+
+import (
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "item_guid"
+	Version = "2"
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor sets stable guid of the item, content based strategies
+// work only for items which provide file contents (see api.FilePool)
+type Perceptor struct {
+	Strategy api.GuidStrategy
+}
+
+func New() *Perceptor {
+	return &Perceptor{Strategy: api.DefaultGuidStrategy}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+func (p *Perceptor) Requires() []api.Field              { return nil }
+func (p *Perceptor) Provides() []api.Field              { return []api.Field{api.FieldGuid} }
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawItemR, chout chan<- api.RawItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawItemR) (api.RawItemR, error) {
+	if err := api.AssignGuid(item, pr.perceptor.Strategy, Name); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
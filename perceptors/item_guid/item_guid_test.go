@@ -0,0 +1,34 @@
+package item_guid
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestDecorate(t *testing.T) {
+	pr := &processor{perceptor: New()}
+
+	item := api.NewRawItem("IMG_0001.JPG", api.RawExif{
+		"ImageUniqueID": []byte("F5D1A0B0C0D0E0F0"),
+	})
+	if _, err := pr.Decorate(item); err != nil {
+		t.Fatal(err)
+	}
+	if item.GetGuid() != "id:f5d1a0b0c0d0e0f0" {
+		t.Errorf("unexpected guid %q", item.GetGuid())
+	}
+
+	renamed := api.NewRawItem("holidays/sea.jpg", api.RawExif{
+		"ImageUniqueID": []byte("F5D1A0B0C0D0E0F0"),
+	})
+	pr.Decorate(renamed)
+	if renamed.GetGuid() != item.GetGuid() {
+		t.Error("guid should not depend on file path")
+	}
+
+	if _, err := pr.Decorate(api.NewRawItem("IMG_0002.JPG", nil)); !errors.Is(err, api.ErrNoGuid) {
+		t.Errorf("expected ErrNoGuid, got %v", err)
+	}
+}
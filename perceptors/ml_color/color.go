@@ -0,0 +1,143 @@
+package ml_color
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Lab is a color in CIE L*a*b* space, euclidean distance in it is close to perceived difference
+type Lab struct {
+	L, A, B float64
+}
+
+func linear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func gamma(v float64) uint8 {
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// D65 white point
+const (
+	whiteX = 0.95047
+	whiteY = 1.0
+	whiteZ = 1.08883
+)
+
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27*t + 16) / 116
+}
+
+func labFInv(t float64) float64 {
+	if t3 := t * t * t; t3 > 216.0/24389 {
+		return t3
+	}
+	return (116*t - 16) * 27 / 24389
+}
+
+// ToLab converts sRGB color, alpha is ignored
+func ToLab(c color.NRGBA) Lab {
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / whiteX
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / whiteY
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / whiteZ
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return Lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// RGB converts color back to sRGB, colors out of gamut are clipped
+func (c Lab) RGB() color.NRGBA {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+	x, y, z := labFInv(fx)*whiteX, labFInv(fy)*whiteY, labFInv(fz)*whiteZ
+
+	return color.NRGBA{
+		R: gamma(3.2404542*x - 1.5371385*y - 0.4985314*z),
+		G: gamma(-0.9692660*x + 1.8760108*y + 0.0415560*z),
+		B: gamma(0.0556434*x - 0.2040259*y + 1.0572252*z),
+		A: 255,
+	}
+}
+
+func (c Lab) distance2(o Lab) float64 {
+	dl, da, db := c.L-o.L, c.A-o.A, c.B-o.B
+	return dl*dl + da*da + db*db
+}
+
+// Hex formats color like "#ff8800"
+func Hex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// namedColor is a reference color for naming palette colors
+type namedColor struct {
+	name string
+	lab  Lab
+}
+
+func named(name string, r, g, b uint8) namedColor {
+	return namedColor{name: name, lab: ToLab(color.NRGBA{r, g, b, 255})}
+}
+
+// names are basic color names people search by
+var names = []namedColor{
+	named("black", 0, 0, 0),
+	named("gray", 128, 128, 128),
+	named("silver", 192, 192, 192),
+	named("white", 255, 255, 255),
+	named("red", 220, 20, 30),
+	named("maroon", 128, 0, 0),
+	named("orange", 255, 140, 0),
+	named("brown", 139, 69, 19),
+	named("beige", 225, 205, 170),
+	named("yellow", 255, 220, 0),
+	named("olive", 128, 128, 0),
+	named("green", 30, 160, 40),
+	named("dark green", 0, 90, 30),
+	named("teal", 0, 128, 128),
+	named("cyan", 0, 200, 220),
+	named("blue", 30, 80, 220),
+	named("sky blue", 135, 195, 235),
+	named("navy", 0, 0, 110),
+	named("purple", 120, 40, 160),
+	named("pink", 255, 150, 190),
+	named("magenta", 220, 0, 180),
+}
+
+// Nearest returns name of the nearest reference color
+func Nearest(c color.NRGBA) string {
+	lab := ToLab(c)
+	best, dist := "", math.Inf(1)
+	for _, n := range names {
+		if d := lab.distance2(n.lab); d < dist {
+			best, dist = n.name, d
+		}
+	}
+	return best
+}
+
+// hsv returns saturation and value of the color from 0 to 1
+func hsv(c color.NRGBA) (s, v float64) {
+	hi := max(c.R, c.G, c.B)
+	lo := min(c.R, c.G, c.B)
+	if hi == 0 {
+		return 0, 0
+	}
+	return float64(hi-lo) / float64(hi), float64(hi) / 255
+}
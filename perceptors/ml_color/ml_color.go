@@ -0,0 +1,170 @@
+package ml_color
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	"github.com/dukobpa3/perceplib/internal/imaging"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "ml_color"
+	Version = "1"
+)
+
+// TagPrefix prefixes names of prominent colors in result tags, e.g. "color:blue"
+const TagPrefix = "color:"
+
+const (
+	DefaultSize      = 64
+	DefaultMinWeight = 0.1
+)
+
+func init() {
+	api.Register(New())
+}
+
+// Perceptor stores palette of the image with weights and names of colors,
+// its average brightness and saturation. Colors covering at least MinWeight of the image become tags
+type Perceptor struct {
+	Analyzer  *Analyzer
+	Size      int     // image is downscaled to fit Size x Size before analysis
+	Preview   bool    // analyze embedded preview or thumbnail when it is present instead of the image
+	MinWeight float64 // min share of the image for color tags
+}
+
+func New() *Perceptor {
+	return &Perceptor{
+		Analyzer:  NewAnalyzer(),
+		Size:      DefaultSize,
+		MinWeight: DefaultMinWeight,
+	}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.RawDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+
+var (
+	_ api.RawPerceptor = (*Perceptor)(nil)
+	_ api.Configurable = (*Perceptor)(nil)
+)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "colors",
+			Type:        api.ConfigInt,
+			Description: "max palette size",
+			Default:     DefaultColors,
+			Min:         api.Limit(1),
+			Max:         api.Limit(16),
+		},
+		{
+			Name:        "size",
+			Type:        api.ConfigInt,
+			Description: "longest side of downscaled image in pixels",
+			Default:     DefaultSize,
+			Min:         api.Limit(8),
+			Max:         api.Limit(512),
+		},
+		{
+			Name:        "preview",
+			Type:        api.ConfigBool,
+			Description: "analyze embedded preview or exif thumbnail instead of decoding the whole image",
+			Default:     false,
+		},
+		{
+			Name:        "min_weight",
+			Type:        api.ConfigFloat,
+			Description: "min share of the image for color tags",
+			Default:     DefaultMinWeight,
+			Min:         api.Limit(0),
+			Max:         api.Limit(1),
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	a := NewAnalyzer()
+	a.Colors = int(cfg.Int("colors"))
+	p.Analyzer = a
+	p.Size = int(cfg.Int("size"))
+	p.Preview = cfg.Bool("preview")
+	p.MinWeight = cfg.Float("min_weight")
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawDataItemR, chout chan<- api.RawDataItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) decode(item api.RawDataItemR) (image.Image, imaging.Source, error) {
+	content := item.GetContent()
+	defer content.Close()
+
+	if pr.perceptor.Preview {
+		if img, err := imaging.DecodePreview(content, item); err == nil {
+			return img, imaging.SourcePreview, nil
+		}
+	}
+	return imaging.Decode(content, item, true)
+}
+
+func (pr *processor) Decorate(item api.RawDataItemR) (api.RawDataItemR, error) {
+	img, src, err := pr.decode(item)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	b := img.Bounds()
+	w, h := imaging.Fit(b.Dx(), b.Dy(), pr.perceptor.Size)
+	a := pr.perceptor.Analyzer.Analyze(imaging.Resize(img, w, h))
+
+	palette, err := json.Marshal(a.Palette)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	result := api.NewResult(pr.perceptor).
+		Set("palette", api.JSONValue(palette)).
+		Set("brightness", api.FloatValue(a.Brightness)).
+		Set("saturation", api.FloatValue(a.Saturation)).
+		Set("source", api.StringValue(string(src)))
+
+	var colors, names []string
+	for _, s := range a.Palette {
+		colors = append(colors, s.Hex)
+		names = api.AppendUniq(names, s.Name)
+		if s.Weight >= pr.perceptor.MinWeight {
+			result.AddTags(TagPrefix + s.Name)
+		}
+	}
+	result.Set("colors", api.StringsValue(colors)).
+		Set("names", api.StringsValue(names))
+	if len(a.Palette) > 0 {
+		result.Set("dominant", api.StringValue(a.Palette[0].Hex)).
+			Set("dominant_name", api.StringValue(a.Palette[0].Name))
+	}
+
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
@@ -0,0 +1,62 @@
+package ml_color
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func TestPerceptor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	fill(img, img.Bounds(), color.NRGBA{220, 20, 30, 255})
+	fill(img, image.Rect(0, 0, 20, 20), color.NRGBA{255, 255, 255, 255})
+
+	path := filepath.Join(t.TempDir(), "red.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	p := New()
+	cfg, err := p.ConfigSchema().Parse(map[string]any{"colors": 3, "size": 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	item := api.NewRawItem(path, nil)
+	pr := &processor{perceptor: p}
+	if _, err := pr.Decorate(api.NewFilePool(0).Wrap(item, path)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, ok := item.GetResults().Get(Name)
+	if !ok {
+		t.Fatal("result should be stored")
+	}
+	if v, _ := r.Get("dominant_name"); v != api.StringValue("red") {
+		t.Errorf("unexpected dominant color %v", v)
+	}
+	if len(r.Tags) != 1 || r.Tags[0] != TagPrefix+"red" {
+		t.Errorf("white corner is too small for a tag, got %v", r.Tags)
+	}
+	if v, _ := r.Get("names"); v.Kind() != api.KindStrings {
+		t.Errorf("unexpected names %v", v)
+	} else if names, _ := v.AsStrings(); len(names) != 2 || names[1] != "white" {
+		t.Errorf("unexpected names %v", names)
+	}
+	if v, _ := r.Get("palette"); v.Kind() != api.KindJSON {
+		t.Errorf("palette should be json, got %v", v)
+	}
+}
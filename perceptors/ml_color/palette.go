@@ -0,0 +1,173 @@
+package ml_color
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"slices"
+)
+
+// Swatch is a palette color with share of pixels close to it
+type Swatch struct {
+	Color  color.NRGBA `json:"-"`
+	Hex    string      `json:"color"`
+	Name   string      `json:"name"`
+	Weight float64     `json:"weight"`
+}
+
+// Analysis is a palette and overall tone of the image
+type Analysis struct {
+	Palette    []Swatch // sorted by weight, heaviest first
+	Brightness float64  // average HSV value from 0 to 1
+	Saturation float64  // average HSV saturation from 0 to 1
+}
+
+// Analyzer clusters pixels with k-means in Lab space
+type Analyzer struct {
+	Colors     int // max palette size
+	Iterations int // max k-means iterations
+}
+
+const (
+	DefaultColors     = 5
+	DefaultIterations = 20
+)
+
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{Colors: DefaultColors, Iterations: DefaultIterations}
+}
+
+// Analyze builds palette of already downscaled image, mostly transparent pixels are skipped.
+// Result is deterministic, clusters are seeded by the farthest pixels
+func (a *Analyzer) Analyze(img *image.NRGBA) Analysis {
+	var (
+		res    Analysis
+		pixels []Lab
+	)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A < 128 {
+				continue
+			}
+			s, v := hsv(c)
+			res.Saturation += s
+			res.Brightness += v
+			pixels = append(pixels, ToLab(c))
+		}
+	}
+	if len(pixels) == 0 {
+		return res
+	}
+	res.Saturation /= float64(len(pixels))
+	res.Brightness /= float64(len(pixels))
+
+	centers, counts := a.kmeans(pixels)
+	for i, c := range centers {
+		if counts[i] == 0 {
+			continue
+		}
+		rgb := c.RGB()
+		res.Palette = append(res.Palette, Swatch{
+			Color:  rgb,
+			Hex:    Hex(rgb),
+			Name:   Nearest(rgb),
+			Weight: float64(counts[i]) / float64(len(pixels)),
+		})
+	}
+	slices.SortStableFunc(res.Palette, func(x, y Swatch) int { return cmp.Compare(y.Weight, x.Weight) })
+	return res
+}
+
+func (a *Analyzer) kmeans(pixels []Lab) ([]Lab, []int) {
+	centers := seed(pixels, max(1, a.Colors))
+	counts := make([]int, len(centers))
+	assign := make([]int, len(pixels))
+	for i := range assign {
+		assign[i] = -1
+	}
+
+	for iter := 0; iter < max(1, a.Iterations); iter++ {
+		changed := false
+		for i, p := range pixels {
+			if k := nearest(centers, p); k != assign[i] {
+				assign[i], changed = k, true
+			}
+		}
+
+		sums := make([]Lab, len(centers))
+		clear(counts)
+		for i, p := range pixels {
+			k := assign[i]
+			sums[k].L += p.L
+			sums[k].A += p.A
+			sums[k].B += p.B
+			counts[k]++
+		}
+		for k, n := range counts {
+			if n > 0 {
+				centers[k] = Lab{sums[k].L / float64(n), sums[k].A / float64(n), sums[k].B / float64(n)}
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+	return centers, counts
+}
+
+func nearest(centers []Lab, p Lab) int {
+	best, dist := 0, p.distance2(centers[0])
+	for k := 1; k < len(centers); k++ {
+		if d := p.distance2(centers[k]); d < dist {
+			best, dist = k, d
+		}
+	}
+	return best
+}
+
+// seed takes pixel closest to the mean, then repeatedly the pixel farthest from chosen ones.
+// Less than k centers are returned if there are not enough distinct pixels
+func seed(pixels []Lab, k int) []Lab {
+	var mean Lab
+	for _, p := range pixels {
+		mean.L += p.L
+		mean.A += p.A
+		mean.B += p.B
+	}
+	n := float64(len(pixels))
+	mean = Lab{mean.L / n, mean.A / n, mean.B / n}
+
+	first, dist := 0, pixels[0].distance2(mean)
+	for i, p := range pixels {
+		if d := p.distance2(mean); d < dist {
+			first, dist = i, d
+		}
+	}
+	centers := []Lab{pixels[first]}
+
+	// distance of every pixel to the nearest chosen center
+	nearestDist := make([]float64, len(pixels))
+	for i, p := range pixels {
+		nearestDist[i] = p.distance2(centers[0])
+	}
+	for len(centers) < k {
+		far, farDist := -1, 0.0
+		for i, d := range nearestDist {
+			if d > farDist {
+				far, farDist = i, d
+			}
+		}
+		if far < 0 {
+			break
+		}
+		c := pixels[far]
+		centers = append(centers, c)
+		for i, p := range pixels {
+			nearestDist[i] = min(nearestDist[i], p.distance2(c))
+		}
+	}
+	return centers
+}
@@ -0,0 +1,69 @@
+package ml_color
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestLab(t *testing.T) {
+	for _, c := range []color.NRGBA{{0, 0, 0, 255}, {255, 255, 255, 255}, {200, 30, 120, 255}, {12, 250, 90, 255}} {
+		if back := ToLab(c).RGB(); back != c {
+			t.Errorf("%v converted back to %v", c, back)
+		}
+	}
+	if lab := ToLab(color.NRGBA{255, 255, 255, 255}); math.Abs(lab.L-100) > 0.01 || math.Abs(lab.A) > 0.01 || math.Abs(lab.B) > 0.01 {
+		t.Errorf("unexpected white %+v", lab)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	tests := map[color.NRGBA]string{
+		{250, 10, 10, 255}:   "red",
+		{10, 10, 10, 255}:    "black",
+		{240, 240, 235, 255}: "white",
+		{40, 70, 200, 255}:   "blue",
+		{250, 200, 20, 255}:  "yellow",
+		{60, 170, 60, 255}:   "green",
+	}
+	for c, expected := range tests {
+		if name := Nearest(c); name != expected {
+			t.Errorf("%s: expected %s, got %s", Hex(c), expected, name)
+		}
+	}
+}
+
+func fill(img *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	fill(img, image.Rect(0, 0, 40, 30), color.NRGBA{30, 80, 220, 255})
+	fill(img, image.Rect(0, 30, 40, 40), color.NRGBA{255, 140, 0, 255})
+	fill(img, image.Rect(0, 0, 40, 2), color.NRGBA{0, 0, 0, 0})
+
+	a := NewAnalyzer().Analyze(img)
+	if len(a.Palette) != 2 {
+		t.Fatalf("expected 2 colors, got %+v", a.Palette)
+	}
+	if s := a.Palette[0]; s.Name != "blue" || s.Hex != "#1e50dc" || math.Abs(s.Weight-28.0/38) > 1e-9 {
+		t.Errorf("unexpected dominant color %+v", s)
+	}
+	if s := a.Palette[1]; s.Name != "orange" || math.Abs(s.Weight-10.0/38) > 1e-9 {
+		t.Errorf("unexpected second color %+v", s)
+	}
+	if math.Abs(a.Saturation-1) > 0.2 || a.Brightness < 0.8 {
+		t.Errorf("unexpected saturation %f and brightness %f", a.Saturation, a.Brightness)
+	}
+
+	empty := NewAnalyzer().Analyze(image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	if len(empty.Palette) != 0 {
+		t.Errorf("transparent image should have no palette, got %+v", empty.Palette)
+	}
+}
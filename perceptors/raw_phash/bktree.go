@@ -0,0 +1,72 @@
+package raw_phash
+
+// BKTree is an index of hashes by Hamming distance, search visits only subtrees
+// which could contain hashes within given distance
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+type bkNode struct {
+	hash     Hash
+	ids      []int // ids of equal hashes
+	children map[int]*bkNode
+}
+
+// Add puts hash with given id into the tree
+func (t *BKTree) Add(hash Hash, id int) {
+	t.size++
+	if t.root == nil {
+		t.root = &bkNode{hash: hash, ids: []int{id}}
+		return
+	}
+
+	node := t.root
+	for {
+		d := node.hash.Distance(hash)
+		if d == 0 {
+			node.ids = append(node.ids, id)
+			return
+		}
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{hash: hash, ids: []int{id}}
+			return
+		}
+		node = child
+	}
+}
+
+// Len returns count of added hashes
+func (t *BKTree) Len() int {
+	return t.size
+}
+
+// Search returns ids of hashes within maxDistance from given hash
+func (t *BKTree) Search(hash Hash, maxDistance int) []int {
+	if t.root == nil {
+		return nil
+	}
+
+	var res []int
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := node.hash.Distance(hash)
+		if d <= maxDistance {
+			res = append(res, node.ids...)
+		}
+		// triangle inequality: hashes of child at distance k are within |d-k| from given one
+		for k, child := range node.children {
+			if k >= d-maxDistance && k <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return res
+}
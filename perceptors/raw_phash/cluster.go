@@ -0,0 +1,96 @@
+package raw_phash
+
+import "slices"
+
+// unionFind keeps disjoint sets of indexes
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	uf := make(unionFind, n)
+	for i := range uf {
+		uf[i] = i
+	}
+	return uf
+}
+
+func (uf unionFind) find(i int) int {
+	for uf[i] != i {
+		uf[i] = uf[uf[i]]
+		i = uf[i]
+	}
+	return i
+}
+
+// union joins sets, the smallest index becomes the root
+func (uf unionFind) union(a, b int) {
+	ra, rb := uf.find(a), uf.find(b)
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	uf[rb] = ra
+}
+
+// Fingerprint is a pair of hashes of single image
+type Fingerprint struct {
+	PHash Hash
+	DHash Hash
+}
+
+// Clusterer joins near-duplicates into clusters, duplicates of duplicates belong to the same cluster
+type Clusterer struct {
+	PHashThreshold int // max pHash distance of duplicates
+	DHashThreshold int // max dHash distance of duplicates, not checked if negative
+}
+
+const (
+	DefaultPHashThreshold = 8
+	DefaultDHashThreshold = 10
+)
+
+func NewClusterer() *Clusterer {
+	return &Clusterer{
+		PHashThreshold: DefaultPHashThreshold,
+		DHashThreshold: DefaultDHashThreshold,
+	}
+}
+
+// Match reports whether fingerprints belong to duplicates
+func (c *Clusterer) Match(a, b Fingerprint) bool {
+	return a.PHash.Distance(b.PHash) <= c.PHashThreshold &&
+		(c.DHashThreshold < 0 || a.DHash.Distance(b.DHash) <= c.DHashThreshold)
+}
+
+// Cluster returns clusters of indexes of given fingerprints, including clusters of single index.
+// Indexes inside clusters are sorted, clusters are ordered by their first index
+func (c *Clusterer) Cluster(fps []Fingerprint) [][]int {
+	uf := newUnionFind(len(fps))
+	var tree BKTree
+	for i, fp := range fps {
+		for _, j := range tree.Search(fp.PHash, c.PHashThreshold) {
+			if c.Match(fps[j], fp) {
+				uf.union(i, j)
+			}
+		}
+		tree.Add(fp.PHash, i)
+	}
+
+	index := make(map[int]int)
+	var clusters [][]int
+	for i := range fps {
+		root := uf.find(i)
+		k, ok := index[root]
+		if !ok {
+			k = len(clusters)
+			index[root] = k
+			clusters = append(clusters, nil)
+		}
+		clusters[k] = append(clusters[k], i)
+	}
+	for _, cl := range clusters {
+		slices.Sort(cl)
+	}
+	return clusters
+}
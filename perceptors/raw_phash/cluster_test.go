@@ -0,0 +1,71 @@
+package raw_phash
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBKTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	hashes := make([]Hash, 2000)
+	var tree BKTree
+	for i := range hashes {
+		hashes[i] = Hash(rnd.Uint64())
+		if i%10 == 0 && i > 0 {
+			// near copies of earlier hashes
+			hashes[i] = hashes[i-1] ^ Hash(1<<uint(rnd.Intn(64)))
+		}
+		tree.Add(hashes[i], i)
+	}
+	if tree.Len() != len(hashes) {
+		t.Errorf("unexpected size %d", tree.Len())
+	}
+
+	for _, max := range []int{0, 3, 20} {
+		for q := 0; q < 50; q++ {
+			query := hashes[rnd.Intn(len(hashes))] ^ Hash(rnd.Uint64()&rnd.Uint64()&rnd.Uint64())
+			var expected []int
+			for i, h := range hashes {
+				if h.Distance(query) <= max {
+					expected = append(expected, i)
+				}
+			}
+			got := tree.Search(query, max)
+			slices.Sort(got)
+			if fmt.Sprint(got) != fmt.Sprint(expected) {
+				t.Fatalf("max %d: expected %v, got %v", max, expected, got)
+			}
+		}
+	}
+}
+
+func TestCluster(t *testing.T) {
+	fp := func(p, d Hash) Fingerprint { return Fingerprint{PHash: p, DHash: d} }
+
+	c := NewClusterer()
+	c.PHashThreshold = 2
+	c.DHashThreshold = 2
+
+	fps := []Fingerprint{
+		fp(0b0000, 0),
+		fp(0xff00, 0),
+		fp(0b0011, 0),    // near the first one
+		fp(0b1111, 0),    // near the third, far from the first: duplicates are transitive
+		fp(0xff01, 0xff), // near by pHash, far by dHash
+		fp(0xff00, 1),
+	}
+	got := c.Cluster(fps)
+	expected := [][]int{{0, 2, 3}, {1, 5}, {4}}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	c.DHashThreshold = -1
+	got = c.Cluster(fps)
+	expected = [][]int{{0, 2, 3}, {1, 4, 5}}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("without dHash: expected %v, got %v", expected, got)
+	}
+}
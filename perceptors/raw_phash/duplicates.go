@@ -0,0 +1,186 @@
+package raw_phash
+
+import (
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const DuplicatesName = "raw_phash_dupes"
+
+// TagDuplicate marks every item of a cluster except its original
+const TagDuplicate = "duplicate"
+
+// Duplicates groups items hashed by Perceptor into clusters of near-duplicates.
+// The largest item of the cluster is its original and goes first in the group,
+// items of clusters with duplicates get result with group id, size of the group and distance to the original.
+// Items without hashes and unique items form groups of single item.
+// Hashes are taken from results kept by items themselves, see FingerprintOf
+type Duplicates struct {
+	Clusterer *Clusterer
+}
+
+func NewDuplicates() *Duplicates {
+	return &Duplicates{Clusterer: NewClusterer()}
+}
+
+func (p *Duplicates) Name() string                       { return DuplicatesName }
+func (p *Duplicates) Version() string                    { return Version }
+func (p *Duplicates) DataProvider() api.DataProviderType { return api.ExifDataProvider }
+func (p *Duplicates) ProcessingMode() api.ProcessingMode { return api.ItemGroup }
+
+var (
+	_ api.GroupPerceptor = (*Duplicates)(nil)
+	_ api.Configurable   = (*Duplicates)(nil)
+)
+
+func (p *Duplicates) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "phash_threshold",
+			Type:        api.ConfigInt,
+			Description: "max pHash distance of duplicates",
+			Default:     DefaultPHashThreshold,
+			Min:         api.Limit(0),
+			Max:         api.Limit(64),
+		},
+		{
+			Name:        "dhash_threshold",
+			Type:        api.ConfigInt,
+			Description: "max dHash distance of duplicates, not checked if negative",
+			Default:     DefaultDHashThreshold,
+			Min:         api.Limit(-1),
+			Max:         api.Limit(64),
+		},
+	}
+}
+
+func (p *Duplicates) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Duplicates) Configure(cfg api.Config) error {
+	c := NewClusterer()
+	c.PHashThreshold = int(cfg.Int("phash_threshold"))
+	c.DHashThreshold = int(cfg.Int("dhash_threshold"))
+	p.Clusterer = c
+	return nil
+}
+
+func (p *Duplicates) NewGroupProcessor(chin <-chan api.RawItemR, chout chan<- api.ItemGroupR, logger *l.Logger) chain.Processor {
+	return chain.NewGroupCollector(chin, chout, &grouper{perceptor: p})
+}
+
+type grouper struct {
+	perceptor *Duplicates
+}
+
+func (gr *grouper) Group(items []api.RawItemR) ([]api.ItemGroupR, error) {
+	var (
+		hashed []int // indexes of items with hashes
+		fps    []Fingerprint
+	)
+	clustered := make([]bool, len(items))
+	for i, item := range items {
+		if fp, ok := FingerprintOf(item); ok {
+			hashed = append(hashed, i)
+			fps = append(fps, fp)
+		}
+	}
+
+	// clusters are ordered by their first item, items without hashes are placed by their position
+	first := make(map[int][]int)
+	var dupes []api.RawItemR // items of clusters with duplicates, they get results
+	for _, cl := range gr.perceptor.Clusterer.Cluster(fps) {
+		for _, j := range cl {
+			clustered[hashed[j]] = true
+			if len(cl) > 1 {
+				dupes = append(dupes, items[hashed[j]])
+			}
+		}
+		first[hashed[cl[0]]] = cl
+	}
+	if err := api.CheckResults(dupes...); err != nil {
+		return nil, fmt.Errorf("%s: %w", DuplicatesName, err)
+	}
+
+	res := make([]api.ItemGroupR, 0, len(items))
+	for i, item := range items {
+		if !clustered[i] {
+			path, _ := api.PathOf(item)
+			res = append(res, &api.Group{ID: "item:" + path, Items: []api.RawItemR{item}})
+			continue
+		}
+		members, ok := first[i]
+		if !ok {
+			continue
+		}
+		g, err := gr.group(items, hashed, fps, members)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, g)
+	}
+	return res, nil
+}
+
+// group builds group of cluster members, members are indexes of fingerprints
+func (gr *grouper) group(items []api.RawItemR, hashed []int, fps []Fingerprint, members []int) (*api.Group, error) {
+	orig := members[0]
+	for _, j := range members[1:] {
+		if area(items[hashed[j]]) > area(items[hashed[orig]]) {
+			orig = j
+		}
+	}
+
+	g := &api.Group{ID: "dupes:" + fps[orig].PHash.String()}
+	g.Items = append(g.Items, items[hashed[orig]])
+	for _, j := range members {
+		if j != orig {
+			g.Items = append(g.Items, items[hashed[j]])
+		}
+	}
+	if len(members) == 1 {
+		return g, nil
+	}
+
+	for _, j := range members {
+		result := api.NewResult(gr.perceptor).
+			Set("group_id", api.StringValue(g.ID)).
+			Set("size", api.IntValue(int64(len(members)))).
+			Set("original", api.BoolValue(j == orig)).
+			Set("distance", api.IntValue(int64(fps[j].PHash.Distance(fps[orig].PHash))))
+		if j != orig {
+			result.AddTags(TagDuplicate)
+		}
+		if err := api.SetResult(items[hashed[j]], result); err != nil {
+			return nil, fmt.Errorf("%s: %w", DuplicatesName, err)
+		}
+	}
+	return g, nil
+}
+
+func (gr *grouper) Stop() {}
+
+// area returns pixel count of the item, size set by earlier perceptors wins over size of hashed image
+func area(item api.RawItemR) int {
+	if size := item.GetSize(); size.W > 0 && size.H > 0 {
+		return size.W * size.H
+	}
+	rs, ok := api.ResultsOf(item)
+	if !ok {
+		return 0
+	}
+	r, ok := rs.Get(Name)
+	if !ok {
+		return 0
+	}
+	w, _ := r.Get("width")
+	h, _ := r.Get("height")
+	wi, _ := w.AsInt()
+	hi, _ := h.AsInt()
+	return int(wi * hi)
+}
@@ -0,0 +1,111 @@
+package raw_phash
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/internal/imaging"
+)
+
+// Hash is a 64 bit perceptual hash, similar images have hashes with small Hamming distance
+type Hash uint64
+
+// Distance returns count of different bits
+func (h Hash) Distance(o Hash) int {
+	return bits.OnesCount64(uint64(h ^ o))
+}
+
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+func ParseHash(s string) (Hash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed hash %q", s)
+	}
+	return Hash(v), nil
+}
+
+// DHash compares brightness of horizontal neighbours of 9x8 grayscale image as it is displayed
+func DHash(img image.Image, o api.Orientation) Hash {
+	w, h := 9, 8
+	if o.SwapsDimensions() {
+		w, h = h, w
+	}
+	g := imaging.Grayscale(img, w, h).Orient(o)
+
+	var hash Hash
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if g.At(x, y) < g.At(x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+const (
+	dctSize  = 32
+	hashSize = 8
+)
+
+// dctTable keeps cosines of DCT-II for the lowest frequencies
+var dctTable = func() [hashSize][dctSize]float64 {
+	var t [hashSize][dctSize]float64
+	for k := range t {
+		for n := range t[k] {
+			t[k][n] = math.Cos(math.Pi / dctSize * (float64(n) + 0.5) * float64(k))
+		}
+	}
+	return t
+}()
+
+// PHash compares the lowest 8x8 frequencies of discrete cosine transform of 32x32 grayscale image
+// with their median, DC component doesn't take part in median
+func PHash(img image.Image, o api.Orientation) Hash {
+	g := imaging.Grayscale(img, dctSize, dctSize).Orient(o)
+
+	// rows first, then columns of the lowest row frequencies only
+	var rows [dctSize][hashSize]float64
+	for y := 0; y < dctSize; y++ {
+		for k := 0; k < hashSize; k++ {
+			sum := 0.0
+			for x := 0; x < dctSize; x++ {
+				sum += g.At(x, y) * dctTable[k][x]
+			}
+			rows[y][k] = sum
+		}
+	}
+
+	coeffs := make([]float64, 0, hashSize*hashSize)
+	for ky := 0; ky < hashSize; ky++ {
+		for kx := 0; kx < hashSize; kx++ {
+			sum := 0.0
+			for y := 0; y < dctSize; y++ {
+				sum += rows[y][kx] * dctTable[ky][y]
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	sorted := slices.Clone(coeffs[1:])
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash Hash
+	for _, c := range coeffs {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
@@ -0,0 +1,77 @@
+package raw_phash
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+// pattern draws image defined in relative coordinates, so resized copies look the same
+func pattern(w, h int, fn func(x, y float64) float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(math.Max(0, math.Min(255, fn((float64(x)+0.5)/float64(w), (float64(y)+0.5)/float64(h)))))
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func waves(x, y float64) float64 {
+	return 128 + 60*math.Sin(7*x) + 60*math.Cos(5*y+3*x*y)
+}
+
+func blobs(x, y float64) float64 {
+	d := math.Hypot(x-0.7, y-0.3)
+	return 255 * math.Abs(math.Sin(12*d)) * (1 - y)
+}
+
+// rotate90 rotates image counter clockwise, so it is displayed correctly with OrientationRotate90
+func rotate90(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	res := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			res.Set(y, b.Dx()-1-x, img.At(x, y))
+		}
+	}
+	return res
+}
+
+func TestHashes(t *testing.T) {
+	orig := pattern(640, 480, waves)
+	resized := pattern(160, 120, waves)
+	rotated := rotate90(pattern(320, 240, waves))
+	other := pattern(640, 480, blobs)
+
+	for name, hash := range map[string]func(image.Image, api.Orientation) Hash{"phash": PHash, "dhash": DHash} {
+		h := hash(orig, api.OrientationNormal)
+		if d := h.Distance(hash(resized, api.OrientationNormal)); d > 4 {
+			t.Errorf("%s: resized copy is too far: %d", name, d)
+		}
+		if d := h.Distance(hash(rotated, api.OrientationRotate90)); d > 4 {
+			t.Errorf("%s: oriented copy is too far: %d", name, d)
+		}
+		if d := h.Distance(hash(other, api.OrientationNormal)); d < 16 {
+			t.Errorf("%s: other image is too close: %d", name, d)
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	h := Hash(0x00ff00ff12345678)
+	if h.String() != "00ff00ff12345678" {
+		t.Errorf("unexpected %s", h)
+	}
+	parsed, err := ParseHash(h.String())
+	if err != nil || parsed != h {
+		t.Errorf("expected %s, got %s %v", h, parsed, err)
+	}
+	if _, err := ParseHash("xyz"); err == nil {
+		t.Error("malformed hash should fail")
+	}
+}
@@ -0,0 +1,123 @@
+package raw_phash
+
+import (
+	"fmt"
+
+	"github.com/dukobpa3/perceplib/api"
+	"github.com/dukobpa3/perceplib/chain"
+	"github.com/dukobpa3/perceplib/internal/imaging"
+	l "github.com/dukobpa3/perceplib/logger"
+)
+
+const (
+	Name    = "raw_phash"
+	Version = "1"
+)
+
+func init() {
+	api.Register(New())
+	api.Register(NewDuplicates())
+}
+
+// Perceptor computes pHash and dHash of the image as it is displayed and stores them in its result.
+// Files which can't be decoded by standard library are hashed by their embedded preview
+type Perceptor struct {
+	Preview bool // use embedded preview of files in other formats
+}
+
+func New() *Perceptor {
+	return &Perceptor{Preview: true}
+}
+
+func (p *Perceptor) Name() string                       { return Name }
+func (p *Perceptor) Version() string                    { return Version }
+func (p *Perceptor) DataProvider() api.DataProviderType { return api.RawDataProvider }
+func (p *Perceptor) ProcessingMode() api.ProcessingMode { return api.SingleItem }
+
+var (
+	_ api.RawPerceptor = (*Perceptor)(nil)
+	_ api.Configurable = (*Perceptor)(nil)
+)
+
+func (p *Perceptor) ConfigSchema() api.ConfigSchema {
+	return api.ConfigSchema{
+		{
+			Name:        "preview",
+			Type:        api.ConfigBool,
+			Description: "hash embedded preview of raw and other files unsupported by decoders",
+			Default:     true,
+		},
+	}
+}
+
+func (p *Perceptor) Clone() api.Perceptor {
+	c := *p
+	return &c
+}
+
+func (p *Perceptor) Configure(cfg api.Config) error {
+	p.Preview = cfg.Bool("preview")
+	return nil
+}
+
+func (p *Perceptor) NewProcessor(chin <-chan api.RawDataItemR, chout chan<- api.RawDataItemR, logger *l.Logger) chain.Processor {
+	return chain.NewDecorator(chin, chout, &processor{perceptor: p})
+}
+
+// FingerprintOf returns hashes stored by the perceptor to the item
+func FingerprintOf(item api.RawItemR) (Fingerprint, bool) {
+	rs, ok := api.ResultsOf(item)
+	if !ok {
+		return Fingerprint{}, false
+	}
+	r, ok := rs.Get(Name)
+	if !ok {
+		return Fingerprint{}, false
+	}
+
+	var fp Fingerprint
+	for key, h := range map[string]*Hash{"phash": &fp.PHash, "dhash": &fp.DHash} {
+		v, _ := r.Get(key)
+		s, ok := v.AsString()
+		if !ok {
+			return Fingerprint{}, false
+		}
+		hash, err := ParseHash(s)
+		if err != nil {
+			return Fingerprint{}, false
+		}
+		*h = hash
+	}
+	return fp, true
+}
+
+type processor struct {
+	perceptor *Perceptor
+}
+
+func (pr *processor) Decorate(item api.RawDataItemR) (api.RawDataItemR, error) {
+	content := item.GetContent()
+	defer content.Close()
+
+	img, src, err := imaging.Decode(content, item, pr.perceptor.Preview)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+
+	o := api.ExifOrientation(item)
+	b := img.Bounds()
+	size := api.OrientedSize(api.Size{W: b.Dx(), H: b.Dy()}, o)
+
+	result := api.NewResult(pr.perceptor).
+		Set("phash", api.StringValue(PHash(img, o).String())).
+		Set("dhash", api.StringValue(DHash(img, o).String())).
+		Set("source", api.StringValue(string(src))).
+		Set("width", api.IntValue(int64(size.W))).
+		Set("height", api.IntValue(int64(size.H)))
+	if err := api.SetResult(item, result); err != nil {
+		return nil, fmt.Errorf("%s: %w", Name, err)
+	}
+	return item, nil
+}
+
+func (pr *processor) Stop() {}
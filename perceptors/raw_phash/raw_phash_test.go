@@ -0,0 +1,73 @@
+package raw_phash
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dukobpa3/perceplib/api"
+)
+
+func hashed(t *testing.T, pool *api.FilePool, name string, img image.Image) *api.RawItem {
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	item := api.NewRawItem(path, nil)
+	pr := &processor{perceptor: New()}
+	if _, err := pr.Decorate(pool.Wrap(item, path)); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestPerceptor(t *testing.T) {
+	pool := api.NewFilePool(1)
+	large := hashed(t, pool, "large.png", pattern(320, 240, waves))
+	small := hashed(t, pool, "small.png", pattern(80, 60, waves))
+	other := hashed(t, pool, "other.png", pattern(320, 240, blobs))
+	unhashed := api.NewRawItem("unhashed.png", nil)
+
+	r, _ := large.GetResults().Get(Name)
+	if v, _ := r.Get("source"); v != api.StringValue("image") {
+		t.Errorf("unexpected source %v", v)
+	}
+	if _, ok := FingerprintOf(large); !ok {
+		t.Fatal("fingerprint should be stored")
+	}
+
+	gr := &grouper{perceptor: NewDuplicates()}
+	groups, err := gr.Group([]api.RawItemR{small, unhashed, other, large})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if items := groups[0].GetItems(); len(items) != 2 || items[0] != large || items[1] != small {
+		t.Errorf("the largest copy should go first, got %v", items)
+	}
+	if items := groups[1].GetItems(); len(items) != 1 || items[0] != unhashed {
+		t.Errorf("unexpected second group %v", items)
+	}
+
+	r, _ = small.GetResults().Get(DuplicatesName)
+	if v, _ := r.Get("original"); v != api.BoolValue(false) || len(r.Tags) != 1 || r.Tags[0] != TagDuplicate {
+		t.Errorf("unexpected duplicate result %+v", r)
+	}
+	r, _ = large.GetResults().Get(DuplicatesName)
+	if v, _ := r.Get("original"); v != api.BoolValue(true) || len(r.Tags) != 0 {
+		t.Errorf("unexpected original result %+v", r)
+	}
+	if _, ok := other.GetResults().Get(DuplicatesName); ok {
+		t.Error("unique item should have no result")
+	}
+}